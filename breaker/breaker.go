@@ -0,0 +1,351 @@
+// Package breaker 熔断器
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"sync"
+	"time"
+)
+
+// State 熔断器的状态
+type State int
+
+const (
+	StateClosed   State = iota // 关闭，正常放行
+	StateOpen                  // 打开，全部拒绝
+	StateHalfOpen              // 半开，放行少量探测请求
+)
+
+var stateNameMap = map[State]string{
+	StateClosed:   "closed",
+	StateOpen:     "open",
+	StateHalfOpen: "half-open",
+}
+
+// String 状态名称
+func (s State) String() string {
+	if name, ok := stateNameMap[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+var (
+	// ErrOpen 熔断器打开，请求被拒绝
+	ErrOpen = errors.New("[breaker] circuit breaker is open")
+	// ErrTooManyRequests 半开状态下探测请求已满
+	ErrTooManyRequests = errors.New("[breaker] too many requests in half-open state")
+)
+
+// Config 熔断器的配置
+type Config struct {
+	Name                string        `json:"name" yaml:"name"`
+	Window              time.Duration `json:"window" yaml:"window"`                           // 统计的滚动窗口，默认10秒
+	Buckets             int           `json:"buckets" yaml:"buckets"`                         // 窗口分为多少个桶，默认10
+	MinRequests         int           `json:"minRequests" yaml:"minRequests"`                 // 窗口内请求数达到后才按失败率判断，默认20
+	FailureRatio        float64       `json:"failureRatio" yaml:"failureRatio"`               // 失败率达到后熔断，默认0.5
+	ConsecutiveFailures int           `json:"consecutiveFailures" yaml:"consecutiveFailures"` // 连续失败次数达到后熔断，0为不启用
+	OpenTimeout         time.Duration `json:"openTimeout" yaml:"openTimeout"`                 // 打开后多久进入半开，默认5秒
+	HalfOpenRequests    int           `json:"halfOpenRequests" yaml:"halfOpenRequests"`       // 半开时的探测请求数，全部成功后关闭，默认1
+
+	// IsFailure 判断错误是否计为失败，默认除 context.Canceled 以外的错误都是失败
+	IsFailure func(err error) bool `json:"-" yaml:"-"`
+	// OnStateChange 状态变化时的回调
+	OnStateChange func(name string, from, to State) `json:"-" yaml:"-"`
+	// Store 在多个实例之间共享打开状态，为空则只在本进程内生效
+	Store StateStore `json:"-" yaml:"-"`
+	// SyncInterval 从 Store 同步状态的间隔，默认1秒
+	SyncInterval time.Duration `json:"syncInterval" yaml:"syncInterval"`
+}
+
+// Counts 当前窗口的统计
+type Counts struct {
+	Success             int `json:"success"`
+	Failures            int `json:"failures"`
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// Breaker 熔断器
+type Breaker struct {
+	cfg Config
+
+	mu               sync.Mutex
+	state            State
+	generation       uint64 // 每次状态变化加1，忽略旧状态下请求的结果
+	openedAt         time.Time
+	window           *rollingWindow
+	consecutive      int
+	halfOpenRequests int
+	halfOpenSuccess  int
+	lastSync         time.Time
+	pendingChanges   []stateChange // 待通知的状态变化，解锁后再回调，避免回调中再调用熔断器导致死锁
+}
+
+type stateChange struct {
+	from, to State
+}
+
+// New 新建熔断器
+func New(cfg *Config) *Breaker {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Window <= 0 {
+		c.Window = 10 * time.Second
+	}
+	if c.Buckets <= 0 {
+		c.Buckets = 10
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = 20
+	}
+	if c.FailureRatio <= 0 || c.FailureRatio > 1 {
+		c.FailureRatio = 0.5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 5 * time.Second
+	}
+	if c.HalfOpenRequests <= 0 {
+		c.HalfOpenRequests = 1
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = defaultIsFailure
+	}
+	if c.SyncInterval <= 0 {
+		c.SyncInterval = time.Second
+	}
+	return &Breaker{
+		cfg:    c,
+		window: newRollingWindow(c.Window, c.Buckets),
+	}
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// Name 熔断器名称
+func (b *Breaker) Name() string {
+	return b.cfg.Name
+}
+
+// State 当前状态
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+	b.refreshState(time.Now())
+	return b.state
+}
+
+// unlock 解锁，然后按顺序回调状态变化
+func (b *Breaker) unlock() {
+	changes := b.pendingChanges
+	b.pendingChanges = nil
+	b.mu.Unlock()
+
+	if b.cfg.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		goroutines.GoSync(func(params ...any) {
+			b.cfg.OnStateChange(b.cfg.Name, change.from, change.to)
+		})
+	}
+}
+
+// Counts 当前窗口的统计
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	success, failures := b.window.counts(time.Now())
+	return Counts{
+		Success:             success,
+		Failures:            failures,
+		ConsecutiveFailures: b.consecutive,
+	}
+}
+
+// Allow 判断是否放行，放行后必须调用 done 上报执行结果
+func (b *Breaker) Allow() (done func(err error), err error) {
+	report, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+	return func(err error) {
+		report(b.cfg.IsFailure(err))
+	}, nil
+}
+
+// allow 判断是否放行，report 只有第一次调用有效
+func (b *Breaker) allow() (report func(failure bool), err error) {
+	b.syncFromStore()
+
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := time.Now()
+	b.refreshState(now)
+	switch b.state {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.halfOpenRequests >= b.cfg.HalfOpenRequests {
+			return nil, ErrTooManyRequests
+		}
+		b.halfOpenRequests++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(failure bool) {
+		once.Do(func() {
+			b.onResult(generation, failure)
+		})
+	}, nil
+}
+
+// reportPanic fn panic时计为失败后继续panic，避免半开状态的计数一直不释放
+func reportPanic(report func(failure bool)) {
+	if r := recover(); r != nil {
+		report(true)
+		panic(r)
+	}
+}
+
+// Do 在熔断器保护下执行fn，fn panic时计为失败
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	report, err := b.allow()
+	if err != nil {
+		return err
+	}
+	defer reportPanic(report)
+	err = fn(ctx)
+	report(b.cfg.IsFailure(err))
+	return err
+}
+
+func (b *Breaker) onResult(generation uint64, failure bool) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := time.Now()
+	b.refreshState(now)
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.window.add(now, failure)
+		if !failure {
+			b.consecutive = 0
+			return
+		}
+		b.consecutive++
+		if b.shouldTrip(now) {
+			b.setState(now, StateOpen, true)
+		}
+	case StateHalfOpen:
+		if failure {
+			b.setState(now, StateOpen, true)
+			return
+		}
+		b.halfOpenSuccess++
+		if b.halfOpenSuccess >= b.cfg.HalfOpenRequests {
+			b.setState(now, StateClosed, true)
+		}
+	}
+}
+
+func (b *Breaker) shouldTrip(now time.Time) bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.consecutive >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+	success, failures := b.window.counts(now)
+	total := success + failures
+	return total >= b.cfg.MinRequests && float64(failures)/float64(total) >= b.cfg.FailureRatio
+}
+
+// refreshState 打开超时后进入半开，需要在加锁后调用
+func (b *Breaker) refreshState(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(now, StateHalfOpen, false)
+	}
+}
+
+// setState 切换状态，需要在加锁后调用，publish 表示是否同步到 Store
+func (b *Breaker) setState(now time.Time, state State, publish bool) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.halfOpenRequests = 0
+	b.halfOpenSuccess = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.reset()
+	}
+
+	if publish && b.cfg.Store != nil {
+		b.publish(state)
+	}
+	b.pendingChanges = append(b.pendingChanges, stateChange{from: from, to: state})
+}
+
+func (b *Breaker) publish(state State) {
+	store := b.cfg.Store
+	name := b.cfg.Name
+	ttl := b.cfg.OpenTimeout
+	goroutines.GoAsync(func(params ...any) {
+		var err error
+		if state == StateOpen {
+			err = store.SetOpen(context.Background(), name, ttl)
+		} else if state == StateClosed {
+			err = store.SetClosed(context.Background(), name)
+		}
+		if err != nil {
+			logs.DefaultLogger().Warn("[breaker] publish state error:", name, state.String(), err.Error())
+		}
+	})
+}
+
+// syncFromStore 按间隔从 Store 读取其他实例的打开状态
+func (b *Breaker) syncFromStore() {
+	if b.cfg.Store == nil {
+		return
+	}
+	b.mu.Lock()
+	now := time.Now()
+	if b.state != StateClosed || now.Sub(b.lastSync) < b.cfg.SyncInterval {
+		b.mu.Unlock()
+		return
+	}
+	b.lastSync = now
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.SyncInterval)
+	defer cancel()
+	open, err := b.cfg.Store.IsOpen(ctx, b.cfg.Name)
+	if err != nil {
+		logs.DefaultLogger().Warn("[breaker] sync state error:", b.cfg.Name, err.Error())
+		return
+	}
+	if !open {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.unlock()
+	if b.state == StateClosed {
+		b.setState(time.Now(), StateOpen, false)
+	}
+}
@@ -0,0 +1,219 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/breaker"
+	"github.com/tianlin0/go-plat-utils/retry"
+)
+
+var errDown = errors.New("dependency down")
+
+func failing(ctx context.Context) error { return errDown }
+func success(ctx context.Context) error { return nil }
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	changes := make(chan breaker.State, 10)
+	b := breaker.New(&breaker.Config{
+		Name:                "db",
+		ConsecutiveFailures: 3,
+		OpenTimeout:         50 * time.Millisecond,
+		OnStateChange: func(name string, from, to breaker.State) {
+			changes <- to
+		},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.ErrorIs(t, b.Do(ctx, failing), errDown)
+	}
+	require.Equal(t, breaker.StateOpen, b.State())
+	require.ErrorIs(t, b.Do(ctx, success), breaker.ErrOpen)
+
+	// 超时后进入半开，探测成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, breaker.StateHalfOpen, b.State())
+	require.Nil(t, b.Do(ctx, success))
+	require.Equal(t, breaker.StateClosed, b.State())
+
+	for _, want := range []breaker.State{breaker.StateOpen, breaker.StateHalfOpen, breaker.StateClosed} {
+		select {
+		case got := <-changes:
+			require.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatal("state change hook not called")
+		}
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b := breaker.New(&breaker.Config{
+		MinRequests:  10,
+		FailureRatio: 0.5,
+		OpenTimeout:  time.Minute,
+	})
+	ctx := context.Background()
+	for i := 0; i < 9; i++ {
+		if i%2 == 0 {
+			_ = b.Do(ctx, failing)
+		} else {
+			_ = b.Do(ctx, success)
+		}
+	}
+	// 请求数不够，不熔断
+	require.Equal(t, breaker.StateClosed, b.State())
+	_ = b.Do(ctx, failing)
+	require.Equal(t, breaker.StateOpen, b.State())
+}
+
+func TestBreakerHalfOpenFailure(t *testing.T) {
+	b := breaker.New(&breaker.Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         20 * time.Millisecond,
+		HalfOpenRequests:    2,
+	})
+	ctx := context.Background()
+	_ = b.Do(ctx, failing)
+	time.Sleep(30 * time.Millisecond)
+
+	done, err := b.Allow()
+	require.Nil(t, err)
+	_, err = b.Allow()
+	require.Nil(t, err)
+	_, err = b.Allow()
+	require.ErrorIs(t, err, breaker.ErrTooManyRequests)
+
+	done(errDown)
+	require.Equal(t, breaker.StateOpen, b.State())
+}
+
+func TestBreakerPanicCountsAsFailure(t *testing.T) {
+	b := breaker.New(&breaker.Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         20 * time.Millisecond,
+		HalfOpenRequests:    1,
+	})
+	ctx := context.Background()
+	_ = b.Do(ctx, failing)
+	time.Sleep(30 * time.Millisecond)
+
+	// 半开状态下panic也要释放计数，否则一直返回 ErrTooManyRequests
+	require.PanicsWithValue(t, "boom", func() {
+		_ = b.Do(ctx, func(ctx context.Context) error { panic("boom") })
+	})
+	require.Equal(t, breaker.StateOpen, b.State())
+	time.Sleep(30 * time.Millisecond)
+	require.Nil(t, b.Do(ctx, func(ctx context.Context) error { return nil }))
+	require.Equal(t, breaker.StateClosed, b.State())
+}
+
+func TestBreakerGroupAndStore(t *testing.T) {
+	s := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	store := breaker.NewRedisStore(cli, "")
+	cfg := &breaker.Config{
+		Name:                "svc:",
+		ConsecutiveFailures: 1,
+		OpenTimeout:         time.Minute,
+		Store:               store,
+		SyncInterval:        time.Millisecond,
+	}
+	g1 := breaker.NewGroup(cfg)
+	g2 := breaker.NewGroup(cfg)
+	ctx := context.Background()
+
+	_ = g1.Do(ctx, "a", failing)
+	require.Equal(t, breaker.StateOpen, g1.Get("a").State())
+	require.Equal(t, breaker.StateClosed, g1.Get("b").State())
+
+	// 另一个实例从redis同步到打开状态
+	require.Eventually(t, func() bool {
+		open, _ := store.IsOpen(ctx, "svc:a")
+		return open
+	}, time.Second, time.Millisecond)
+	require.ErrorIs(t, g2.Do(ctx, "a", success), breaker.ErrOpen)
+}
+
+func TestBreakerGroupStatesCallback(t *testing.T) {
+	var g *breaker.Group
+	g = breaker.NewGroup(&breaker.Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         20 * time.Millisecond,
+		OnStateChange: func(name string, from, to breaker.State) {
+			// 回调中新建其他key的熔断器
+			if to == breaker.StateHalfOpen {
+				g.Get("other")
+			}
+		},
+	})
+	_ = g.Do(context.Background(), "a", failing)
+	time.Sleep(30 * time.Millisecond)
+
+	states := make(chan map[string]breaker.State, 1)
+	go func() {
+		states <- g.States()
+	}()
+	select {
+	case got := <-states:
+		require.Equal(t, breaker.StateHalfOpen, got["a"])
+	case <-time.After(time.Second):
+		t.Fatal("States deadlocks when the state change hook calls Get")
+	}
+	require.Equal(t, breaker.StateClosed, g.Get("other").State())
+}
+
+func TestRetryStopsWhenOpen(t *testing.T) {
+	b := breaker.New(&breaker.Config{ConsecutiveFailures: 2, OpenTimeout: time.Minute})
+	var calls int32
+	err := retry.New().WithInterval(time.Millisecond).WithAttemptCount(10).WithBreaker(b).
+		Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errDown
+		})
+	require.NotNil(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware(t *testing.T) {
+	g := breaker.NewGroup(&breaker.Config{ConsecutiveFailures: 1, OpenTimeout: time.Minute})
+	handler := breaker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}), g, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusBadGateway, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestMiddlewarePanicAndFlush(t *testing.T) {
+	g := breaker.NewGroup(&breaker.Config{ConsecutiveFailures: 1, OpenTimeout: time.Minute})
+	handler := breaker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		_, _ = w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+	}), g, func(r *http.Request) string { return r.URL.Path })
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	require.True(t, w.Flushed)
+
+	require.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	require.Equal(t, breaker.StateOpen, g.Get("/panic").State())
+}
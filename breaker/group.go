@@ -0,0 +1,67 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 按key管理一组熔断器，如每个下游地址一个熔断器
+type Group struct {
+	cfg      Config
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup 新建熔断器组，每个key的熔断器都使用相同的配置，名称为 cfg.Name + key
+func NewGroup(cfg *Config) *Group {
+	g := &Group{
+		breakers: make(map[string]*Breaker),
+	}
+	if cfg != nil {
+		g.cfg = *cfg
+	}
+	return g
+}
+
+// Get 取得key对应的熔断器，不存在则新建
+func (g *Group) Get(key string) *Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[key]; ok {
+		return b
+	}
+	cfg := g.cfg
+	cfg.Name = g.cfg.Name + key
+	b = New(&cfg)
+	g.breakers[key] = b
+	return b
+}
+
+// Do 在key对应的熔断器保护下执行fn
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	return g.Get(key).Do(ctx, fn)
+}
+
+// States 所有熔断器的当前状态
+func (g *Group) States() map[string]State {
+	// State 可能触发状态变化的回调，回调中可能调用 Get，不能在持有锁时调用
+	g.mu.RLock()
+	breakers := make(map[string]*Breaker, len(g.breakers))
+	for key, b := range g.breakers {
+		breakers[key] = b
+	}
+	g.mu.RUnlock()
+
+	states := make(map[string]State, len(breakers))
+	for key, b := range breakers {
+		states[key] = b.State()
+	}
+	return states
+}
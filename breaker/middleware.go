@@ -0,0 +1,50 @@
+package breaker
+
+import (
+	"github.com/tianlin0/go-plat-utils/limiter"
+	"net/http"
+)
+
+// statusRecorder 记录响应的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush 支持流式响应
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 取得原始的 ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware HTTP熔断中间件，按 keyFunc 取得熔断器（为空时共用一个），5xx响应和panic计为失败，熔断时返回 503
+func Middleware(next http.Handler, g *Group, keyFunc limiter.KeyFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ""
+		if keyFunc != nil {
+			key = keyFunc(r)
+		}
+		report, err := g.Get(key).allow()
+		if err != nil {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		// handler panic时由 net/http 恢复，这里先计为失败
+		defer reportPanic(report)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		report(rec.status >= http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,57 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// StateStore 在多个实例之间共享熔断器的打开状态
+type StateStore interface {
+	// IsOpen 熔断器是否已被某个实例打开
+	IsOpen(ctx context.Context, name string) (bool, error)
+	// SetOpen 打开熔断器，ttl 后自动失效
+	SetOpen(ctx context.Context, name string, ttl time.Duration) error
+	// SetClosed 关闭熔断器
+	SetClosed(ctx context.Context, name string) error
+}
+
+// redisStore 使用redis共享熔断状态
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore 新建redis状态存储，prefix 为key的前缀
+func NewRedisStore(client *redis.Client, prefix string) StateStore {
+	if prefix == "" {
+		prefix = "breaker:"
+	}
+	return &redisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// IsOpen 是否已打开
+func (s *redisStore) IsOpen(ctx context.Context, name string) (bool, error) {
+	_, err := s.client.Get(ctx, s.prefix+name).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SetOpen 打开
+func (s *redisStore) SetOpen(ctx context.Context, name string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+name, StateOpen.String(), ttl).Err()
+}
+
+// SetClosed 关闭
+func (s *redisStore) SetClosed(ctx context.Context, name string) error {
+	return s.client.Del(ctx, s.prefix+name).Err()
+}
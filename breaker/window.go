@@ -0,0 +1,60 @@
+package breaker
+
+import "time"
+
+// bucket 一个时间桶内的统计
+type bucket struct {
+	start    int64 // 桶的起始时间，纳秒
+	success  int
+	failures int
+}
+
+// rollingWindow 按时间滚动的统计窗口，由多个桶组成，过期的桶会被重置
+type rollingWindow struct {
+	buckets []bucket
+	width   int64 // 每个桶的时长，纳秒
+}
+
+func newRollingWindow(window time.Duration, buckets int) *rollingWindow {
+	return &rollingWindow{
+		buckets: make([]bucket, buckets),
+		width:   int64(window) / int64(buckets),
+	}
+}
+
+// current 取得当前时间所在的桶，桶已过期则重置
+func (w *rollingWindow) current(now time.Time) *bucket {
+	start := now.UnixNano() / w.width * w.width
+	b := &w.buckets[(start/w.width)%int64(len(w.buckets))]
+	if b.start != start {
+		*b = bucket{start: start}
+	}
+	return b
+}
+
+func (w *rollingWindow) add(now time.Time, failure bool) {
+	b := w.current(now)
+	if failure {
+		b.failures++
+	} else {
+		b.success++
+	}
+}
+
+// counts 统计窗口内有效的成功和失败数
+func (w *rollingWindow) counts(now time.Time) (success, failures int) {
+	oldest := now.UnixNano()/w.width*w.width - w.width*int64(len(w.buckets)-1)
+	for _, b := range w.buckets {
+		if b.start >= oldest {
+			success += b.success
+			failures += b.failures
+		}
+	}
+	return
+}
+
+func (w *rollingWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
@@ -62,3 +62,12 @@ func Wrap(err error, code ...int) error {
 	}
 	return New(errStr, tempCode)
 }
+
+// GetCode 取得错误链中 CommError 的错误码
+func GetCode(err error) (int, bool) {
+	var errTemp CommError
+	if errors.As(err, &errTemp) {
+		return errTemp.Code(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,180 @@
+package crontab
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/robfig/cron/v3"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MissedPolicy 停机期间错过的执行的处理方式
+type MissedPolicy int
+
+const (
+	MissedSkip    MissedPolicy = iota // 不补执行
+	MissedRunOnce                     // 启动时补执行一次
+	MissedRunAll                      // 启动时按计划时间逐个补执行，最多 MaxCatchUp 次
+)
+
+// ClusterConfig 多实例部署时的配置
+type ClusterConfig struct {
+	LockPrefix string        `json:"lockPrefix" yaml:"lockPrefix"` // 锁的key前缀，默认 crontab:lock:
+	LockTTL    time.Duration `json:"lockTTL" yaml:"lockTTL"`       // 锁的过期时间，需要大于各实例的时钟误差，默认10分钟
+	Host       string        `json:"host" yaml:"host"`             // 当前实例的名称，默认为主机名
+	// LockFailOpen 加锁时redis出错的处理：false 不执行，redis不可用期间所有实例都不执行；
+	// true 照常执行，redis不可用期间可能多个实例都执行
+	LockFailOpen bool `json:"lockFailOpen" yaml:"lockFailOpen"`
+
+	Missed     MissedPolicy `json:"missed" yaml:"missed"`         // 错过的执行的处理方式，需要设置 HistoryStore
+	MaxCatchUp int          `json:"maxCatchUp" yaml:"maxCatchUp"` // MissedRunAll 时最多补执行的次数，默认10
+}
+
+// WithCluster 开启多实例模式，每次执行前用redis按任务名和计划时间加锁，只有一个实例会执行
+//
+// 计划时间需要各实例一致，@every 这种从启动时间开始计算的表达式不适用；
+// client 为空时不加锁，只使用 Missed 补执行
+func (s *Scheduler) WithCluster(client *redis.Client, cfg *ClusterConfig) *Scheduler {
+	c := ClusterConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.LockPrefix == "" {
+		c.LockPrefix = "crontab:lock:"
+	}
+	if c.LockTTL <= 0 {
+		c.LockTTL = 10 * time.Minute
+	}
+	if c.MaxCatchUp <= 0 {
+		c.MaxCatchUp = 10
+	}
+	if c.Host == "" {
+		c.Host = defaultHost()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redisClient = client
+	s.cluster = c
+	return s
+}
+
+// WithHistory 设置执行记录的存储，错过的执行也根据其中最近一次的计划时间计算
+func (s *Scheduler) WithHistory(store HistoryStore) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = store
+	return s
+}
+
+// History 任务最近的执行记录
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]RunRecord, error) {
+	s.mu.Lock()
+	store := s.history
+	s.mu.Unlock()
+	if store == nil {
+		return nil, fmt.Errorf("[crontab] history store not set")
+	}
+	return store.List(ctx, name, limit)
+}
+
+func defaultHost() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "pid-" + strconv.Itoa(os.Getpid())
+	}
+	return host
+}
+
+// tryLock 按任务名和计划时间加锁，不主动释放，避免执行很快时其他实例在同一个计划时间再执行
+func (s *Scheduler) tryLock(ctx context.Context, name string, scheduled time.Time) (bool, error) {
+	if s.redisClient == nil {
+		return true, nil
+	}
+	key := s.cluster.LockPrefix + name + ":" + strconv.FormatInt(scheduled.Unix(), 10)
+	return s.redisClient.SetNX(ctx, key, s.cluster.Host, s.cluster.LockTTL).Result()
+}
+
+// entryScheduledTime 本次执行的计划时间，使用 cron 触发时记录的 entry.Prev，与执行的延迟无关；
+// entry 已被删除等取不到时按 scheduledTime 计算
+func entryScheduledTime(entry cron.Entry, schedule cron.Schedule, now time.Time, loc *time.Location) time.Time {
+	if !entry.Prev.IsZero() {
+		return entry.Prev.In(loc)
+	}
+	return scheduledTime(schedule, now, loc)
+}
+
+// scheduledTime 计算本次执行的计划时间，即不晚于 now 的最近一次，只往前查找1分钟，
+// 延迟超过1分钟时按 now 取整到秒，需要优先使用 entryScheduledTime。
+// 没有 CRON_TZ 的表达式按传入时间的时区计算，需要先转到 cron 所用的时区 loc，否则各机器的本地时区不同时计划时间不一致
+func scheduledTime(schedule cron.Schedule, now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	if schedule == nil {
+		return now.Truncate(time.Second)
+	}
+	var last time.Time
+	for next := schedule.Next(now.Add(-time.Minute)); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		last = next
+	}
+	if last.IsZero() {
+		return now.Truncate(time.Second)
+	}
+	return last
+}
+
+// missedRuns 计算 last 之后到 now 之前错过的计划时间，最多 max 个，时区的处理同 scheduledTime
+func missedRuns(schedule cron.Schedule, last, now time.Time, max int, loc *time.Location) []time.Time {
+	last, now = last.In(loc), now.In(loc)
+	list := make([]time.Time, 0)
+	for next := schedule.Next(last); !next.IsZero() && next.Before(now); next = schedule.Next(next) {
+		list = append(list, next)
+		if len(list) > max {
+			list = list[1:]
+		}
+	}
+	return list
+}
+
+// catchUp 按 MissedPolicy 补执行停机期间错过的任务
+func (s *Scheduler) catchUp(ctx context.Context) {
+	s.mu.Lock()
+	store := s.history
+	policy := s.cluster.Missed
+	maxCatchUp := s.cluster.MaxCatchUp
+	jobs := make(map[string]cron.Schedule, len(s.jobs))
+	for name, job := range s.jobs {
+		if !job.info.Paused && job.schedule != nil {
+			jobs[name] = job.schedule
+		}
+	}
+	s.mu.Unlock()
+	if store == nil || policy == MissedSkip {
+		return
+	}
+
+	now := time.Now()
+	for name, schedule := range jobs {
+		last, ok, err := store.Last(ctx, name)
+		if err != nil {
+			logs.DefaultLogger().Warn("[crontab] load last run error:", name, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		missed := missedRuns(schedule, last.ScheduledAt, now, maxCatchUp, s.cron.Location())
+		if len(missed) == 0 {
+			continue
+		}
+		if policy == MissedRunOnce {
+			missed = missed[len(missed)-1:]
+		}
+		logs.DefaultLogger().Info("[crontab] catch up missed runs:", name, len(missed))
+		for _, scheduled := range missed {
+			s.runJob(name, scheduled, false)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package crontab
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleTimeLocation(t *testing.T) {
+	// cron 按配置的时区执行，服务器本地时区不同时计划时间也要一致
+	loc := defaultLocation()
+	local, err := time.LoadLocation("America/New_York")
+	require.Nil(t, err)
+	require.NotEqual(t, loc.String(), local.String())
+
+	schedule, err := ParseSpec("0 0 3 * * *")
+	require.Nil(t, err)
+
+	fired := time.Date(2024, 5, 10, 3, 0, 0, 0, loc)
+	now := fired.Add(20 * time.Millisecond).In(local)
+	require.True(t, scheduledTime(schedule, now, loc).Equal(fired))
+	// 同一次执行在不同时区的机器上得到相同的锁
+	require.True(t, scheduledTime(schedule, now.In(time.UTC), loc).Equal(fired))
+
+	// 从历史记录读出的时间是本地时区
+	last := time.Unix(fired.Add(-48*time.Hour).Unix(), 0).In(local)
+	missed := missedRuns(schedule, last, now.Add(-time.Second), 10, loc)
+	require.Len(t, missed, 1)
+	require.True(t, missed[0].Equal(fired.Add(-24*time.Hour)))
+	require.Equal(t, 3, missed[0].In(loc).Hour())
+}
+
+func TestEntryScheduledTime(t *testing.T) {
+	loc := defaultLocation()
+	schedule, err := ParseSpec("*/5 * * * * *")
+	require.Nil(t, err)
+
+	// 秒级的任务延迟超过1分钟执行时，仍使用 cron 触发时的计划时间
+	fired := time.Date(2024, 5, 10, 3, 0, 5, 0, loc)
+	now := fired.Add(3 * time.Minute)
+	require.True(t, entryScheduledTime(cron.Entry{Prev: fired}, schedule, now, loc).Equal(fired))
+	// 取不到 entry 时按 now 计算
+	require.True(t, entryScheduledTime(cron.Entry{}, schedule, now, loc).Equal(now))
+}
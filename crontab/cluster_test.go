@@ -0,0 +1,133 @@
+package crontab_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/crontab"
+)
+
+func TestClusterRunsOnce(t *testing.T) {
+	s := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	history := crontab.NewRedisHistoryStore(cli, "", 10)
+
+	var runs int32
+	job := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+	schedulers := make([]*crontab.Scheduler, 0)
+	for _, host := range []string{"host-a", "host-b", "host-c"} {
+		one := crontab.NewScheduler(cron.WithSeconds()).
+			WithCluster(cli, &crontab.ClusterConfig{Host: host}).
+			WithHistory(history)
+		require.Nil(t, one.AddJob("report", "* * * * * *", job))
+		schedulers = append(schedulers, one)
+	}
+	for _, one := range schedulers {
+		one.Start()
+	}
+	time.Sleep(2200 * time.Millisecond)
+	for _, one := range schedulers {
+		require.Nil(t, one.Stop(context.Background()))
+	}
+
+	records, err := schedulers[0].History(context.Background(), "report", 0)
+	require.Nil(t, err)
+	require.Equal(t, int(atomic.LoadInt32(&runs)), len(records))
+	// 每个计划时间只执行一次
+	seen := make(map[int64]bool)
+	for _, one := range records {
+		require.False(t, seen[one.ScheduledAt.Unix()])
+		seen[one.ScheduledAt.Unix()] = true
+	}
+	require.GreaterOrEqual(t, len(records), 2)
+}
+
+func TestClusterLockError(t *testing.T) {
+	s := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr(), MaxRetries: -1})
+	s.Close()
+
+	for _, failOpen := range []bool{false, true} {
+		var runs int32
+		one := crontab.NewScheduler(cron.WithSeconds()).
+			WithCluster(cli, &crontab.ClusterConfig{LockFailOpen: failOpen})
+		require.Nil(t, one.AddJob("report", "* * * * * *", func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}))
+		one.Start()
+		require.Eventually(t, func() bool {
+			info, _ := one.Get("report")
+			return info.LockErrors > 0 && (!failOpen || info.Runs > 0)
+		}, 3*time.Second, 10*time.Millisecond)
+		require.Nil(t, one.Stop(context.Background()))
+
+		info, _ := one.Get("report")
+		require.Equal(t, int64(0), info.Skipped)
+		if failOpen {
+			require.Equal(t, info.Runs, int64(atomic.LoadInt32(&runs)))
+		} else {
+			// redis不可用时默认不执行，记录加锁错误
+			require.Equal(t, int32(0), atomic.LoadInt32(&runs))
+			require.Contains(t, info.LastError, "lock job error")
+		}
+	}
+}
+
+func TestSQLiteHistoryAndCatchUp(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "history.db"))
+	require.Nil(t, err)
+	defer db.Close()
+	store, err := crontab.NewSQLiteHistoryStore(db, "")
+	require.Nil(t, err)
+	_, err = crontab.NewSQLiteHistoryStore(db, "bad name;")
+	require.NotNil(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	last := now.Add(-5 * time.Hour).Truncate(time.Hour)
+	require.Nil(t, store.Save(ctx, crontab.RunRecord{
+		Job: "hourly", Host: "old", ScheduledAt: last, StartAt: last, EndAt: last.Add(time.Second), Error: "failed",
+	}))
+	record, ok, err := store.Last(ctx, "hourly")
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, "failed", record.Error)
+	require.Equal(t, time.Second, record.Duration)
+	require.True(t, record.ScheduledAt.Equal(last))
+
+	// 停机期间错过了4次或5次，最多补执行3次
+	var runs int32
+	s := crontab.NewScheduler().WithHistory(store).WithCluster(nil, &crontab.ClusterConfig{
+		Missed:     crontab.MissedRunAll,
+		MaxCatchUp: 3,
+	})
+	require.Nil(t, s.AddJob("hourly", "0 * * * *", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return errors.New("still failing")
+	}))
+	s.Start()
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) == 3
+	}, time.Second, time.Millisecond)
+	require.Nil(t, s.Stop(ctx))
+
+	list, err := s.History(ctx, "hourly", 10)
+	require.Nil(t, err)
+	require.Len(t, list, 4)
+	require.True(t, list[0].ScheduledAt.After(list[1].ScheduledAt))
+	require.True(t, list[0].ScheduledAt.Before(now))
+}
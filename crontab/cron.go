@@ -3,7 +3,6 @@ package crontab
 import (
 	"fmt"
 	"github.com/robfig/cron/v3"
-	"github.com/tianlin0/go-plat-utils/goroutines"
 	"log"
 	"sync"
 )
@@ -24,7 +23,7 @@ func getCron() *cronInstance {
 	cOnce.Do(func() {
 		oneCrontab = &cronInstance{
 			isStart: false,
-			c:       cron.New(),
+			c:       cron.New(append(defaultOptions(), cron.WithChain(recoverCronJob))...),
 		}
 	})
 	return oneCrontab
@@ -43,7 +42,9 @@ month      = field(fields[4], months)
 dayOfWeek  = field(fields[5], dow)
 */
 
-// StartJobs 启动定时任务，格式：分钟 小时 天 月 星期
+// StartJobs 启动定时任务，格式：分钟 小时 天 月 星期，也支持 秒 分钟 小时 天 月 星期、@every 1m 和 CRON_TZ=
+//
+// Deprecated: 任务不能删除，相同时间的任务也不能放在同一个map中，请使用 NewScheduler
 func StartJobs(jobs ...map[string]func()) error {
 	runningMu.Lock()
 	defer runningMu.Unlock()
@@ -84,10 +85,7 @@ func StartJobs(jobs ...map[string]func()) error {
 		return nil
 	}
 	oneCron.isStart = true
-	//异步启动
-	goroutines.GoAsync(func(params ...interface{}) {
-		oneCron.c.Run()
-		select {}
-	})
+	//Start 会在自己的协程中调度，不会阻塞
+	oneCron.c.Start()
 	return nil
 }
@@ -0,0 +1,166 @@
+package crontab
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"regexp"
+	"time"
+)
+
+// RunRecord 一次执行的记录
+type RunRecord struct {
+	Job         string        `json:"job"`
+	Host        string        `json:"host"`
+	ScheduledAt time.Time     `json:"scheduledAt"` // 计划执行的时间
+	StartAt     time.Time     `json:"startAt"`
+	EndAt       time.Time     `json:"endAt"`
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// HistoryStore 保存执行记录
+type HistoryStore interface {
+	// Save 保存一次执行记录
+	Save(ctx context.Context, record RunRecord) error
+	// List 返回最近的 limit 条记录，最新的在前
+	List(ctx context.Context, job string, limit int) ([]RunRecord, error)
+	// Last 最近一次执行的记录，没有时返回false
+	Last(ctx context.Context, job string) (RunRecord, bool, error)
+}
+
+type redisHistoryStore struct {
+	client *redis.Client
+	prefix string
+	maxLen int64
+}
+
+// NewRedisHistoryStore 用redis列表保存执行记录，每个任务最多保留 maxLen 条，默认100
+func NewRedisHistoryStore(client *redis.Client, prefix string, maxLen int) HistoryStore {
+	if prefix == "" {
+		prefix = "crontab:history:"
+	}
+	if maxLen <= 0 {
+		maxLen = 100
+	}
+	return &redisHistoryStore{client: client, prefix: prefix, maxLen: int64(maxLen)}
+}
+
+func (r *redisHistoryStore) Save(ctx context.Context, record RunRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	key := r.prefix + record.Job
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, r.maxLen-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisHistoryStore) List(ctx context.Context, job string, limit int) ([]RunRecord, error) {
+	if limit <= 0 {
+		limit = int(r.maxLen)
+	}
+	values, err := r.client.LRange(ctx, r.prefix+job, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]RunRecord, 0, len(values))
+	for _, one := range values {
+		record := RunRecord{}
+		if err = json.Unmarshal([]byte(one), &record); err != nil {
+			return nil, err
+		}
+		list = append(list, record)
+	}
+	return list, nil
+}
+
+func (r *redisHistoryStore) Last(ctx context.Context, job string) (RunRecord, bool, error) {
+	list, err := r.List(ctx, job, 1)
+	if err != nil || len(list) == 0 {
+		return RunRecord{}, false, err
+	}
+	return list[0], true, nil
+}
+
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type sqlHistoryStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLiteHistoryStore 用SQLite保存执行记录，表不存在时自动创建，table 默认为 crontab_history
+func NewSQLiteHistoryStore(db *sql.DB, table string) (HistoryStore, error) {
+	if table == "" {
+		table = "crontab_history"
+	}
+	if !tableNameRegexp.MatchString(table) {
+		return nil, fmt.Errorf("[crontab] invalid table name: %s", table)
+	}
+	createSql := fmt.Sprintf(`create table if not exists %s (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  job varchar(128) not null,
+  host varchar(128) not null,
+  scheduled_at bigint not null,
+  start_at bigint not null,
+  end_at bigint not null,
+  error text not null
+)`, table)
+	if _, err := db.Exec(createSql); err != nil {
+		return nil, err
+	}
+	indexSql := fmt.Sprintf("create index if not exists idx_%s_job on %s (job, scheduled_at)", table, table)
+	if _, err := db.Exec(indexSql); err != nil {
+		return nil, err
+	}
+	return &sqlHistoryStore{db: db, table: table}, nil
+}
+
+func (s *sqlHistoryStore) Save(ctx context.Context, record RunRecord) error {
+	insertSql := fmt.Sprintf("insert into %s (job, host, scheduled_at, start_at, end_at, error) values (?, ?, ?, ?, ?, ?)", s.table)
+	_, err := s.db.ExecContext(ctx, insertSql, record.Job, record.Host, record.ScheduledAt.UnixNano(),
+		record.StartAt.UnixNano(), record.EndAt.UnixNano(), record.Error)
+	return err
+}
+
+func (s *sqlHistoryStore) List(ctx context.Context, job string, limit int) ([]RunRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	querySql := fmt.Sprintf("select job, host, scheduled_at, start_at, end_at, error from %s where job = ? "+
+		"order by scheduled_at desc, id desc limit ?", s.table)
+	rows, err := s.db.QueryContext(ctx, querySql, job, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]RunRecord, 0)
+	for rows.Next() {
+		var scheduledAt, startAt, endAt int64
+		record := RunRecord{}
+		if err = rows.Scan(&record.Job, &record.Host, &scheduledAt, &startAt, &endAt, &record.Error); err != nil {
+			return nil, err
+		}
+		record.ScheduledAt = time.Unix(0, scheduledAt)
+		record.StartAt = time.Unix(0, startAt)
+		record.EndAt = time.Unix(0, endAt)
+		record.Duration = record.EndAt.Sub(record.StartAt)
+		list = append(list, record)
+	}
+	return list, rows.Err()
+}
+
+func (s *sqlHistoryStore) Last(ctx context.Context, job string) (RunRecord, bool, error) {
+	list, err := s.List(ctx, job, 1)
+	if err != nil || len(list) == 0 {
+		return RunRecord{}, false, err
+	}
+	return list[0], true, nil
+}
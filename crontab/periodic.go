@@ -0,0 +1,318 @@
+package crontab
+
+import (
+	"context"
+	"errors"
+	"github.com/tianlin0/go-plat-utils/cleaner"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Mode 周期任务的计时方式
+type Mode int
+
+const (
+	FixedRate  Mode = iota // 按固定频率执行，从上一次开始时计时
+	FixedDelay             // 上一次执行完成后再等待间隔时间
+)
+
+// OverlapPolicy 固定频率时，上一次还没执行完的处理方式
+type OverlapPolicy int
+
+const (
+	OverlapSkip           OverlapPolicy = iota // 跳过本次
+	OverlapQueue                               // 上一次完成后立即执行，最多排队一次
+	OverlapCancelPrevious                      // 取消上一次，执行本次
+)
+
+var (
+	errTaskPanic = errors.New("[crontab] periodic task panic")
+	// ErrAlreadyStarted 周期任务已经启动
+	ErrAlreadyStarted = errors.New("[crontab] periodic task already started")
+)
+
+// PeriodicConfig 周期任务的配置
+type PeriodicConfig struct {
+	Name           string        `json:"name" yaml:"name"`
+	Interval       time.Duration `json:"interval" yaml:"interval"`             // 执行间隔
+	Jitter         float64       `json:"jitter" yaml:"jitter"`                 // 间隔的随机浮动比例，如0.1为±10%
+	Mode           Mode          `json:"mode" yaml:"mode"`                     // 计时方式，默认 FixedRate
+	Overlap        OverlapPolicy `json:"overlap" yaml:"overlap"`               // 重叠时的处理方式，默认 OverlapSkip
+	Timeout        time.Duration `json:"timeout" yaml:"timeout"`               // 每次执行的超时时间，0为不限
+	MaxBackoff     time.Duration `json:"maxBackoff" yaml:"maxBackoff"`         // 大于0时，连续失败后间隔按2倍增长，不超过该值
+	RunOnStart     bool          `json:"runOnStart" yaml:"runOnStart"`         // 启动时立即执行一次
+	DisableCleaner bool          `json:"disableCleaner" yaml:"disableCleaner"` // 不注册到 cleaner，需要自己调用 Stop
+}
+
+// PeriodicStats 周期任务的运行统计
+type PeriodicStats struct {
+	Name                string        `json:"name"`
+	Runs                int64         `json:"runs"`
+	Failures            int64         `json:"failures"`
+	Skipped             int64         `json:"skipped"`
+	ConsecutiveFailures int64         `json:"consecutiveFailures"`
+	Running             int           `json:"running"`
+	LastRun             time.Time     `json:"lastRun"`
+	LastDuration        time.Duration `json:"lastDuration"`
+	LastError           string        `json:"lastError,omitempty"`
+	NextRun             time.Time     `json:"nextRun"`
+}
+
+// PeriodicTask 周期任务
+type PeriodicTask struct {
+	cfg  PeriodicConfig
+	task func(ctx context.Context) error
+
+	mu           sync.Mutex
+	interval     time.Duration
+	stats        PeriodicStats
+	pending      bool
+	cancelLast   context.CancelFunc
+	cancelLoop   context.CancelFunc
+	loopDone     chan struct{}
+	intervalChan chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewPeriodic 新建周期任务，task 返回错误或 panic 计为失败，未设置 DisableCleaner 时注册到 cleaner
+func NewPeriodic(cfg PeriodicConfig, task func(ctx context.Context) error) *PeriodicTask {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	} else if cfg.Jitter > 1 {
+		cfg.Jitter = 1
+	}
+	p := &PeriodicTask{
+		cfg:          cfg,
+		task:         task,
+		interval:     cfg.Interval,
+		stats:        PeriodicStats{Name: cfg.Name},
+		intervalChan: make(chan struct{}, 1),
+	}
+	if !cfg.DisableCleaner {
+		cleaner.Register(p)
+	}
+	return p
+}
+
+// Name 任务名称，实现 cleaner.Cleanable
+func (p *PeriodicTask) Name() string {
+	return p.cfg.Name
+}
+
+// Start 启动周期任务，ctx 结束或调用 Stop 后停止
+func (p *PeriodicTask) Start(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	if p.loopDone != nil {
+		p.mu.Unlock()
+		return ErrAlreadyStarted
+	}
+	ctx, p.cancelLoop = context.WithCancel(ctx)
+	done := make(chan struct{})
+	p.loopDone = done
+	p.mu.Unlock()
+
+	// 循环一直运行到停止，不占用全局协程池
+	go p.loop(ctx, done)
+	return nil
+}
+
+// Stop 停止周期任务，并等待正在执行的任务完成，实现 cleaner.Cleanable，停止后可以再次 Start
+func (p *PeriodicTask) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancelLoop, p.loopDone
+	p.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	p.wg.Wait()
+
+	p.mu.Lock()
+	if p.loopDone == done {
+		p.cancelLoop = nil
+		p.loopDone = nil
+	}
+	p.mu.Unlock()
+}
+
+// SetInterval 修改执行间隔，立即生效
+func (p *PeriodicTask) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.interval = interval
+	p.mu.Unlock()
+	select {
+	case p.intervalChan <- struct{}{}:
+	default:
+	}
+}
+
+// Stats 运行统计
+func (p *PeriodicTask) Stats() PeriodicStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *PeriodicTask) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	last := time.Now()
+	next := last
+	if !p.cfg.RunOnStart {
+		next = last.Add(p.nextDelay())
+	}
+	for {
+		p.setNextRun(next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-p.intervalChan:
+			timer.Stop()
+			next = last.Add(p.nextDelay())
+			continue
+		case <-timer.C:
+		}
+
+		last = time.Now()
+		if p.cfg.Mode == FixedDelay {
+			p.wg.Add(1)
+			p.run(ctx)
+			next = time.Now().Add(p.nextDelay())
+			continue
+		}
+		p.fire(ctx)
+		next = next.Add(p.nextDelay())
+		if now := time.Now(); next.Before(now) {
+			// 错过的不补，避免集中执行
+			next = now.Add(p.nextDelay())
+		}
+	}
+}
+
+// fire 固定频率时按重叠策略执行
+func (p *PeriodicTask) fire(ctx context.Context) {
+	p.mu.Lock()
+	if p.stats.Running > 0 {
+		switch p.cfg.Overlap {
+		case OverlapQueue:
+			if p.pending {
+				p.stats.Skipped++
+			}
+			p.pending = true
+			p.mu.Unlock()
+			return
+		case OverlapCancelPrevious:
+			if p.cancelLast != nil {
+				p.cancelLast()
+			}
+		default:
+			p.stats.Skipped++
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	goroutines.GoAsync(func(params ...any) {
+		p.run(ctx)
+	})
+}
+
+// run 执行一次，调用前需要 wg.Add(1)
+func (p *PeriodicTask) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if p.cfg.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, p.cfg.Timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	p.mu.Lock()
+	p.stats.Running++
+	p.cancelLast = cancel
+	p.mu.Unlock()
+
+	start := time.Now()
+	err := p.call(runCtx)
+
+	p.mu.Lock()
+	p.stats.Running--
+	p.stats.Runs++
+	p.stats.LastRun = start
+	p.stats.LastDuration = time.Since(start)
+	if err != nil {
+		p.stats.Failures++
+		p.stats.ConsecutiveFailures++
+		p.stats.LastError = err.Error()
+	} else {
+		p.stats.ConsecutiveFailures = 0
+		p.stats.LastError = ""
+	}
+	again := p.pending && ctx.Err() == nil
+	p.pending = false
+	if again {
+		p.wg.Add(1)
+	}
+	p.mu.Unlock()
+
+	if again {
+		p.run(ctx)
+	}
+}
+
+// call 执行任务，panic 交给 goroutines 的Panic处理方法并计为失败
+func (p *PeriodicTask) call(ctx context.Context) (err error) {
+	err = errTaskPanic
+	goroutines.GoSync(func(params ...any) {
+		err = p.task(ctx)
+	})
+	return err
+}
+
+// nextDelay 下一次执行前的等待时间，包含失败退避和随机浮动
+func (p *PeriodicTask) nextDelay() time.Duration {
+	p.mu.Lock()
+	delay := p.interval
+	failures := p.stats.ConsecutiveFailures
+	p.mu.Unlock()
+
+	if failures > 0 && p.cfg.MaxBackoff > 0 {
+		backoff := float64(delay) * math.Pow(2, float64(failures))
+		if backoff > float64(p.cfg.MaxBackoff) {
+			backoff = float64(p.cfg.MaxBackoff)
+		}
+		if time.Duration(backoff) > delay {
+			delay = time.Duration(backoff)
+		}
+	}
+	if p.cfg.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + (rand.Float64()*2-1)*p.cfg.Jitter))
+	}
+	return delay
+}
+
+func (p *PeriodicTask) setNextRun(next time.Time) {
+	p.mu.Lock()
+	p.stats.NextRun = next
+	p.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package crontab_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/crontab"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestPeriodicFixedRateSkip(t *testing.T) {
+	var runs int32
+	p := crontab.NewPeriodic(crontab.PeriodicConfig{
+		Name:           "skip",
+		Interval:       10 * time.Millisecond,
+		RunOnStart:     true,
+		DisableCleaner: true,
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(35 * time.Millisecond)
+		return nil
+	})
+	require.Nil(t, p.Start(context.Background()))
+	require.ErrorIs(t, p.Start(context.Background()), crontab.ErrAlreadyStarted)
+	time.Sleep(100 * time.Millisecond)
+	p.Stop()
+
+	stats := p.Stats()
+	require.Equal(t, int64(atomic.LoadInt32(&runs)), stats.Runs)
+	require.Greater(t, stats.Skipped, int64(0))
+	require.Equal(t, 0, stats.Running)
+}
+
+func TestPeriodicRestartWithRoutinePool(t *testing.T) {
+	// 循环不占用协程池，只有一个 worker 时任务也能执行
+	goroutines.OpenRoutinePool(1)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	var runs int32
+	p := crontab.NewPeriodic(crontab.PeriodicConfig{
+		Interval:       5 * time.Millisecond,
+		RunOnStart:     true,
+		Timeout:        time.Second,
+		DisableCleaner: true,
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	require.Nil(t, p.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 2
+	}, time.Second, time.Millisecond)
+	p.Stop()
+
+	// 停止后可以再次启动
+	stopped := atomic.LoadInt32(&runs)
+	require.Nil(t, p.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) > stopped
+	}, time.Second, time.Millisecond)
+	p.Stop()
+}
+
+func TestPeriodicCancelPrevious(t *testing.T) {
+	var cancelled int32
+	p := crontab.NewPeriodic(crontab.PeriodicConfig{
+		Interval:       10 * time.Millisecond,
+		Overlap:        crontab.OverlapCancelPrevious,
+		DisableCleaner: true,
+	}, func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+		return ctx.Err()
+	})
+	require.Nil(t, p.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cancelled) >= 2
+	}, time.Second, time.Millisecond)
+	p.Stop()
+	require.Equal(t, 0, p.Stats().Running)
+}
+
+func TestPeriodicFixedDelayBackoff(t *testing.T) {
+	var runs int32
+	p := crontab.NewPeriodic(crontab.PeriodicConfig{
+		Interval:       5 * time.Millisecond,
+		Mode:           crontab.FixedDelay,
+		MaxBackoff:     time.Minute,
+		DisableCleaner: true,
+	}, func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("boom")
+		}
+		return errors.New("failed")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, p.Start(ctx))
+	// 5ms, 10ms, 20ms, 40ms... 连续失败后间隔变长
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+	p.Stop()
+
+	stats := p.Stats()
+	require.LessOrEqual(t, stats.Runs, int64(4))
+	require.Equal(t, stats.Runs, stats.Failures)
+	require.Equal(t, "failed", stats.LastError)
+
+	p.SetInterval(time.Second)
+}
@@ -0,0 +1,348 @@
+package crontab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/robfig/cron/v3"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("[crontab] job not found")
+	// ErrJobExists 同名的任务已存在
+	ErrJobExists = errors.New("[crontab] job already exists")
+	// ErrSchedulerStopped 调度器已停止
+	ErrSchedulerStopped = errors.New("[crontab] scheduler stopped")
+)
+
+// JobFunc 定时任务，ctx 在调度器停止超时后取消
+type JobFunc func(ctx context.Context) error
+
+// JobInfo 定时任务的信息
+type JobInfo struct {
+	Name         string        `json:"name"`
+	Spec         string        `json:"spec"`
+	Paused       bool          `json:"paused"`
+	Running      int           `json:"running"`
+	Runs         int64         `json:"runs"`
+	Failures     int64         `json:"failures"`
+	Skipped      int64         `json:"skipped"`    // 多实例模式下被其他实例执行的次数
+	LockErrors   int64         `json:"lockErrors"` // 多实例模式下加锁出错的次数，错误记录在 LastError
+	LastRun      time.Time     `json:"lastRun"`
+	LastDuration time.Duration `json:"lastDuration"`
+	LastError    string        `json:"lastError,omitempty"`
+	NextRun      time.Time     `json:"nextRun"`
+}
+
+type jobEntry struct {
+	info     JobInfo
+	fn       JobFunc
+	entryId  cron.EntryID
+	schedule cron.Schedule
+}
+
+// Scheduler 按名称管理定时任务的调度器
+type Scheduler struct {
+	cron   *cron.Cron
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	jobs     map[string]*jobEntry
+	started  bool
+	stopped  bool
+	running  sync.WaitGroup
+	wrappers []JobWrapper
+
+	redisClient *redis.Client
+	cluster     ClusterConfig
+	history     HistoryStore
+}
+
+// NewScheduler 新建调度器，默认支持秒、描述符和 CRON_TZ，时区为 conf.TimeLocation()，
+// opts 为 cron 的配置，会覆盖默认配置
+func NewScheduler(opts ...cron.Option) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cron:   cron.New(append(defaultOptions(), opts...)...),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*jobEntry),
+	}
+}
+
+// Use 添加所有任务的中间件，如 Recover()、Logging()，只对之后添加的任务生效
+func (s *Scheduler) Use(wrappers ...JobWrapper) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wrappers = append(s.wrappers, wrappers...)
+	return s
+}
+
+// AddJob 添加命名的定时任务，spec 为 cron 表达式，wrappers 为只对该任务生效的中间件，在 Use 添加的之后执行
+func (s *Scheduler) AddJob(name, spec string, fn JobFunc, wrappers ...JobWrapper) error {
+	if fn == nil {
+		return fmt.Errorf("[crontab] job %s func is nil", name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+	allWrappers := append(append([]JobWrapper{}, s.wrappers...), wrappers...)
+	job := &jobEntry{
+		info: JobInfo{Name: name, Spec: spec},
+		fn:   chainJob(name, fn, allWrappers...),
+	}
+	// 用于计算计划时间，失败时由 cron 的解析器报错
+	job.schedule, _ = ParseSpec(spec)
+	if err := s.schedule(job); err != nil {
+		return err
+	}
+	s.jobs[name] = job
+	return nil
+}
+
+// schedule 将任务加入 cron，需要在加锁后调用
+func (s *Scheduler) schedule(job *jobEntry) error {
+	name := job.info.Name
+	entryId, err := s.cron.AddFunc(job.info.Spec, func() {
+		s.mu.Lock()
+		entryId := job.entryId
+		s.mu.Unlock()
+		scheduled := entryScheduledTime(s.cron.Entry(entryId), job.schedule, time.Now(), s.cron.Location())
+		s.runJob(name, scheduled, false)
+	})
+	if err != nil {
+		return fmt.Errorf("[crontab] job %s spec %s error: %w", name, job.info.Spec, err)
+	}
+	job.entryId = entryId
+	return nil
+}
+
+// Remove 删除任务，正在执行的不受影响
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if !job.info.Paused {
+		s.cron.Remove(job.entryId)
+	}
+	delete(s.jobs, name)
+	return nil
+}
+
+// Pause 暂停任务，暂停期间不会按时间执行，TriggerNow 仍然可以执行
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if job.info.Paused {
+		return nil
+	}
+	s.cron.Remove(job.entryId)
+	job.entryId = 0
+	job.info.Paused = true
+	return nil
+}
+
+// Resume 恢复暂停的任务
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if !job.info.Paused {
+		return nil
+	}
+	if err := s.schedule(job); err != nil {
+		return err
+	}
+	job.info.Paused = false
+	return nil
+}
+
+// TriggerNow 立即异步执行一次任务
+func (s *Scheduler) TriggerNow(name string) error {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	stopped := s.stopped
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if stopped {
+		return ErrSchedulerStopped
+	}
+	goroutines.GoAsync(func(params ...any) {
+		s.runJob(name, time.Now(), true)
+	})
+	return nil
+}
+
+// List 所有任务的信息，按名称排序
+func (s *Scheduler) List() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		info := job.info
+		if !info.Paused {
+			info.NextRun = s.cron.Entry(job.entryId).Next
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get 取得任务的信息
+func (s *Scheduler) Get(name string) (JobInfo, bool) {
+	for _, one := range s.List() {
+		if one.Name == name {
+			return one, true
+		}
+	}
+	return JobInfo{}, false
+}
+
+// Start 开始调度，不会阻塞，设置了 MissedPolicy 时会先异步补执行错过的任务
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	s.cron.Start()
+	goroutines.GoAsync(func(params ...any) {
+		s.catchUp(s.ctx)
+	})
+}
+
+// Stop 停止调度，等待正在执行的任务完成，ctx 结束时取消任务的ctx并返回 ctx 的错误
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	<-s.cron.Stop().Done()
+
+	done := make(chan struct{})
+	// 等待的协程不占用全局协程池，ctx 超时返回后还会等到任务结束
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// runJob 执行一次任务并记录结果，manual 为手动触发，不加锁
+func (s *Scheduler) runJob(name string, scheduled time.Time, manual bool) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	if !ok || s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.running.Add(1)
+	fn := job.fn
+	store := s.history
+	s.mu.Unlock()
+	defer s.running.Done()
+
+	if !manual {
+		locked, err := s.tryLock(s.ctx, name, scheduled)
+		if err != nil {
+			logs.DefaultLogger().Warn("[crontab] lock job error:", name, err.Error())
+			s.mu.Lock()
+			job.info.LockErrors++
+			job.info.LastError = fmt.Sprintf("[crontab] lock job error: %v", err)
+			failOpen := s.cluster.LockFailOpen
+			s.mu.Unlock()
+			if !failOpen {
+				return
+			}
+		} else if !locked {
+			s.mu.Lock()
+			job.info.Skipped++
+			s.mu.Unlock()
+			return
+		}
+	}
+
+	s.mu.Lock()
+	job.info.Running++
+	s.mu.Unlock()
+
+	start := time.Now()
+	err := goroutines.RunSafe(func() error {
+		return fn(s.ctx)
+	})
+	end := time.Now()
+
+	s.mu.Lock()
+	job.info.Running--
+	job.info.Runs++
+	job.info.LastRun = start
+	job.info.LastDuration = end.Sub(start)
+	if err != nil {
+		job.info.Failures++
+		job.info.LastError = err.Error()
+	} else {
+		job.info.LastError = ""
+	}
+	s.mu.Unlock()
+
+	if store == nil {
+		return
+	}
+	record := RunRecord{
+		Job:         name,
+		Host:        s.host(),
+		ScheduledAt: scheduled,
+		StartAt:     start,
+		EndAt:       end,
+		Duration:    end.Sub(start),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if saveErr := store.Save(context.Background(), record); saveErr != nil {
+		logs.DefaultLogger().Warn("[crontab] save run record error:", name, saveErr.Error())
+	}
+}
+
+func (s *Scheduler) host() string {
+	if s.cluster.Host != "" {
+		return s.cluster.Host
+	}
+	return defaultHost()
+}
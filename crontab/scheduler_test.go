@@ -0,0 +1,113 @@
+package crontab_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/crontab"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestScheduler(t *testing.T) {
+	s := crontab.NewScheduler(cron.WithSeconds())
+	var a, b int32
+	require.Nil(t, s.AddJob("a", "* * * * * *", func(ctx context.Context) error {
+		atomic.AddInt32(&a, 1)
+		return nil
+	}))
+	// 相同时间的任务可以同时存在
+	require.Nil(t, s.AddJob("b", "* * * * * *", func(ctx context.Context) error {
+		atomic.AddInt32(&b, 1)
+		return errors.New("failed")
+	}))
+	require.ErrorIs(t, s.AddJob("a", "* * * * * *", func(ctx context.Context) error { return nil }), crontab.ErrJobExists)
+	require.NotNil(t, s.AddJob("c", "bad spec", func(ctx context.Context) error { return nil }))
+
+	s.Start()
+	require.Nil(t, s.Pause("b"))
+	info, ok := s.Get("b")
+	require.True(t, ok)
+	require.True(t, info.Paused)
+	require.True(t, info.NextRun.IsZero())
+
+	require.Nil(t, s.TriggerNow("b"))
+	require.Eventually(t, func() bool {
+		info, _ := s.Get("b")
+		return info.Runs == 1 && info.LastError == "failed"
+	}, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&a) >= 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&b))
+
+	list := s.List()
+	require.Len(t, list, 2)
+	require.Equal(t, "a", list[0].Name)
+	require.False(t, list[0].NextRun.IsZero())
+
+	require.Nil(t, s.Resume("b"))
+	require.Nil(t, s.Remove("a"))
+	require.ErrorIs(t, s.Remove("a"), crontab.ErrJobNotFound)
+	require.Len(t, s.List(), 1)
+	require.Nil(t, s.Stop(context.Background()))
+}
+
+func TestSchedulerStopWaits(t *testing.T) {
+	s := crontab.NewScheduler()
+	started := make(chan struct{})
+	var finished int32
+	require.Nil(t, s.AddJob("slow", "@every 1h", func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-time.After(50 * time.Millisecond):
+			atomic.StoreInt32(&finished, 1)
+		case <-ctx.Done():
+		}
+		return nil
+	}))
+	s.Start()
+	require.Nil(t, s.TriggerNow("slow"))
+	<-started
+	require.Nil(t, s.Stop(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(&finished))
+	require.ErrorIs(t, s.TriggerNow("slow"), crontab.ErrSchedulerStopped)
+}
+
+func TestSchedulerStopTimeoutWithRoutinePool(t *testing.T) {
+	goroutines.OpenRoutinePool(2)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	s := crontab.NewScheduler()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.Nil(t, s.AddJob("slow", "@every 1h", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+	s.Start()
+	require.Nil(t, s.TriggerNow("slow"))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+
+	// Stop 超时返回后，等待任务结束的协程不占用协程池
+	probe := make(chan struct{})
+	go goroutines.GoAsync(func(params ...any) {
+		close(probe)
+	})
+	select {
+	case <-probe:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop waiter holds a routine pool worker")
+	}
+	close(release)
+}
@@ -0,0 +1,69 @@
+package crontab
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"github.com/tianlin0/go-plat-utils/conf"
+	"time"
+)
+
+// specParser 支持5位（分 时 日 月 周）和6位（秒 分 时 日 月 周）格式，
+// 以及 @every 1m、@daily 等描述符和 CRON_TZ=Asia/Tokyo 前缀
+var specParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// defaultLocation 默认时区，使用 conf.TimeLocation()，取不到时使用本地时区
+func defaultLocation() *time.Location {
+	if loc := conf.TimeLocation(); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// defaultOptions 默认的 cron 配置
+func defaultOptions() []cron.Option {
+	return []cron.Option{
+		cron.WithParser(specParser),
+		cron.WithLocation(defaultLocation()),
+	}
+}
+
+// ParseSpec 解析 cron 表达式
+func ParseSpec(spec string) (cron.Schedule, error) {
+	return specParser.Parse(spec)
+}
+
+// NextRuns 返回 from 之后的 n 次执行时间，没有 CRON_TZ 的表达式使用 conf.TimeLocation() 时区
+func NextRuns(spec string, n int, from time.Time) ([]time.Time, error) {
+	schedule, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]time.Time, 0, n)
+	next := from.In(defaultLocation())
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		list = append(list, next)
+	}
+	return list, nil
+}
+
+// ValidateSpec 校验 cron 表达式，返回从现在开始的 n 次执行时间，如 "2024-01-02 15:04:05 CST"，用于配置页面展示
+func ValidateSpec(spec string, n int) ([]string, error) {
+	list, err := NextRuns(spec, n, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("[crontab] invalid spec %q: %w", spec, err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("[crontab] spec %q never fires", spec)
+	}
+	ret := make([]string, 0, len(list))
+	for _, one := range list {
+		ret = append(ret, one.Format("2006-01-02 15:04:05 MST"))
+	}
+	return ret, nil
+}
@@ -0,0 +1,49 @@
+package crontab_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/conf"
+	"github.com/tianlin0/go-plat-utils/crontab"
+)
+
+func TestNextRuns(t *testing.T) {
+	loc := conf.TimeLocation()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+
+	// 5位，分钟级
+	list, err := crontab.NextRuns("30 8 * * *", 2, from)
+	require.Nil(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 8, 30, 0, 0, loc),
+		time.Date(2024, 1, 2, 8, 30, 0, 0, loc),
+	}, list)
+
+	// 6位，秒级
+	list, err = crontab.NextRuns("*/10 * * * * *", 3, from)
+	require.Nil(t, err)
+	require.Equal(t, 10*time.Second, list[1].Sub(list[0]))
+
+	// 描述符
+	list, err = crontab.NextRuns("@daily", 1, from)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, loc), list[0])
+	list, err = crontab.NextRuns("@every 90s", 1, from)
+	require.Nil(t, err)
+	require.Equal(t, from.Add(90*time.Second), list[0])
+
+	// CRON_TZ 指定时区，东京比上海早一小时
+	list, err = crontab.NextRuns("CRON_TZ=Asia/Tokyo 0 9 * * *", 1, from)
+	require.Nil(t, err)
+	require.True(t, list[0].Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, loc)))
+
+	_, err = crontab.ValidateSpec("61 * * * *", 3)
+	require.NotNil(t, err)
+	_, err = crontab.ValidateSpec("0 0 30 2 *", 3)
+	require.NotNil(t, err)
+	desc, err := crontab.ValidateSpec("@hourly", 3)
+	require.Nil(t, err)
+	require.Len(t, desc, 3)
+}
@@ -0,0 +1,109 @@
+package crontab
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"github.com/tianlin0/go-plat-utils/utils/httputil"
+	"sync"
+	"time"
+)
+
+// JobWrapper 任务的中间件，name 为任务名称，每个任务调用一次
+type JobWrapper func(name string, next JobFunc) JobFunc
+
+// chainJob 按顺序包装，第一个在最外层
+func chainJob(name string, fn JobFunc, wrappers ...JobWrapper) JobFunc {
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		if wrappers[i] != nil {
+			fn = wrappers[i](name, fn)
+		}
+	}
+	return fn
+}
+
+// Recover 任务 panic 时交给 goroutines.SetDefaultPanicHandle 设置的方法，并转为错误
+func Recover() JobWrapper {
+	return func(name string, next JobFunc) JobFunc {
+		return func(ctx context.Context) error {
+			return goroutines.RunSafe(func() error {
+				return next(ctx)
+			})
+		}
+	}
+}
+
+// Timeout 每次执行的ctx在 timeout 后取消
+func Timeout(timeout time.Duration) JobWrapper {
+	return func(name string, next JobFunc) JobFunc {
+		return func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx)
+		}
+	}
+}
+
+// SkipIfStillRunning 上一次还在执行时跳过本次
+func SkipIfStillRunning() JobWrapper {
+	return func(name string, next JobFunc) JobFunc {
+		running := make(chan struct{}, 1)
+		return func(ctx context.Context) error {
+			select {
+			case running <- struct{}{}:
+				defer func() { <-running }()
+				return next(ctx)
+			default:
+				logs.CtxLogger(ctx).Info("[crontab] job still running, skip:", name)
+				return nil
+			}
+		}
+	}
+}
+
+// DelayIfStillRunning 上一次还在执行时等待其完成后再执行
+func DelayIfStillRunning() JobWrapper {
+	return func(name string, next JobFunc) JobFunc {
+		var mu sync.Mutex
+		return func(ctx context.Context) error {
+			start := time.Now()
+			mu.Lock()
+			defer mu.Unlock()
+			if delay := time.Since(start); delay > time.Minute {
+				logs.CtxLogger(ctx).Info("[crontab] job delayed:", name, delay.String())
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Logging 每次执行生成新的日志ID，通过 logs.CtxLogger 记录开始、结束和错误，
+// 任务中用 logs.CtxLogger(ctx) 打印的日志使用相同的日志ID
+func Logging() JobWrapper {
+	return func(name string, next JobFunc) JobFunc {
+		return func(ctx context.Context) error {
+			ctx = goroutines.WithBaggage(ctx, goroutines.Baggage{LogId: httputil.GetLogId()})
+			logger, ctx := logs.NewCtxLogger(ctx, logs.GetConfig().LogLevel, nil)
+
+			start := time.Now()
+			logger.Info("[crontab] job start:", name)
+			err := next(ctx)
+			if err != nil {
+				logger.Error("[crontab] job failed:", name, time.Since(start).String(), err.Error())
+				return err
+			}
+			logger.Info("[crontab] job done:", name, time.Since(start).String())
+			return nil
+		}
+	}
+}
+
+// recoverCronJob StartJobs 的任务 panic 时交给 goroutines.SetDefaultPanicHandle 设置的方法
+func recoverCronJob(job cron.Job) cron.Job {
+	return cron.FuncJob(func() {
+		goroutines.GoSync(func(params ...any) {
+			job.Run()
+		})
+	})
+}
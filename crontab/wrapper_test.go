@@ -0,0 +1,104 @@
+package crontab_test
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/crontab"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+var (
+	panicHandleOnce sync.Once
+	panicCount      atomic.Pointer[int32]
+)
+
+// recordPanics 统计当前测试中交给全局 panic 方法的次数，全局方法只设置一次，测试结束后恢复为打印日志
+func recordPanics(t *testing.T) *int32 {
+	panicHandleOnce.Do(func() {
+		goroutines.SetDefaultPanicHandle(func(err error, retRecover any) {
+			if count := panicCount.Load(); count != nil {
+				atomic.AddInt32(count, 1)
+				return
+			}
+			log.Println(err.Error())
+		})
+	})
+	count := new(int32)
+	panicCount.Store(count)
+	t.Cleanup(func() { panicCount.Store(nil) })
+	return count
+}
+
+func TestJobWrappers(t *testing.T) {
+	panics := recordPanics(t)
+
+	var mu sync.Mutex
+	order := make([]string, 0)
+	trace := func(tag string) crontab.JobWrapper {
+		return func(name string, next crontab.JobFunc) crontab.JobFunc {
+			return func(ctx context.Context) error {
+				mu.Lock()
+				order = append(order, tag+":"+name)
+				mu.Unlock()
+				return next(ctx)
+			}
+		}
+	}
+
+	s := crontab.NewScheduler().Use(crontab.Recover(), crontab.Logging(), trace("global"))
+	logIds := make(chan string, 1)
+	require.Nil(t, s.AddJob("panic", "@every 1h", func(ctx context.Context) error {
+		baggage, _ := goroutines.BaggageFromContext(ctx)
+		logIds <- baggage.LogId
+		panic("boom")
+	}, trace("job")))
+
+	var running int32
+	release := make(chan struct{})
+	require.Nil(t, s.AddJob("slow", "@every 1h", func(ctx context.Context) error {
+		atomic.AddInt32(&running, 1)
+		<-release
+		return nil
+	}, crontab.SkipIfStillRunning()))
+
+	var deadline int32
+	require.Nil(t, s.AddJob("timeout", "@every 1h", func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.StoreInt32(&deadline, 1)
+		return ctx.Err()
+	}, crontab.Timeout(10*time.Millisecond)))
+
+	require.Nil(t, s.TriggerNow("panic"))
+	require.NotEmpty(t, <-logIds)
+	require.Eventually(t, func() bool {
+		info, _ := s.Get("panic")
+		return info.Failures == 1
+	}, time.Second, time.Millisecond)
+	info, _ := s.Get("panic")
+	require.Contains(t, info.LastError, "boom")
+	require.Equal(t, int32(1), atomic.LoadInt32(panics))
+	mu.Lock()
+	require.Equal(t, []string{"global:panic", "job:panic"}, order)
+	mu.Unlock()
+
+	require.Nil(t, s.TriggerNow("slow"))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 1 }, time.Second, time.Millisecond)
+	require.Nil(t, s.TriggerNow("slow"))
+	// 第二次被 SkipIfStillRunning 跳过
+	require.Eventually(t, func() bool {
+		info, _ := s.Get("slow")
+		return info.Runs == 1
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&running))
+	close(release)
+
+	require.Nil(t, s.TriggerNow("timeout"))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&deadline) == 1 }, time.Second, time.Millisecond)
+	require.Nil(t, s.Stop(context.Background()))
+}
@@ -0,0 +1,103 @@
+package goroutines
+
+import (
+	"context"
+)
+
+type baggageKey struct{}
+
+// Baggage 跟随上下文在协程之间传递的数据
+type Baggage struct {
+	LogId   string            `json:"logId,omitempty"`
+	UserId  string            `json:"userId,omitempty"`
+	TraceId string            `json:"traceId,omitempty"`
+	Extend  map[string]string `json:"extend,omitempty"`
+}
+
+// WithBaggage 将 baggage 合并到ctx中，非空的字段覆盖已有的值
+func WithBaggage(ctx context.Context, baggage Baggage) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged, _ := BaggageFromContext(ctx)
+	if baggage.LogId != "" {
+		merged.LogId = baggage.LogId
+	}
+	if baggage.UserId != "" {
+		merged.UserId = baggage.UserId
+	}
+	if baggage.TraceId != "" {
+		merged.TraceId = baggage.TraceId
+	}
+	if len(baggage.Extend) > 0 {
+		extend := make(map[string]string, len(merged.Extend)+len(baggage.Extend))
+		for k, v := range merged.Extend {
+			extend[k] = v
+		}
+		for k, v := range baggage.Extend {
+			extend[k] = v
+		}
+		merged.Extend = extend
+	}
+	return context.WithValue(ctx, baggageKey{}, merged)
+}
+
+// BaggageFromContext 从ctx中取得 baggage
+func BaggageFromContext(ctx context.Context) (Baggage, bool) {
+	if ctx == nil {
+		return Baggage{}, false
+	}
+	baggage, ok := ctx.Value(baggageKey{}).(Baggage)
+	return baggage, ok
+}
+
+// Carrier 在创建异步任务时捕获当前上下文，显式传给任务，不依赖协程ID查找上下文
+type Carrier struct {
+	ctx context.Context
+}
+
+// Capture 捕获ctx，ctx为空时兼容从 GetContext 中取得入口处设置的上下文
+func Capture(ctx context.Context) *Carrier {
+	if ctx == nil {
+		if ctxPtr, _, _ := GetContext(); ctxPtr != nil {
+			ctx = *ctxPtr
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Carrier{ctx: ctx}
+}
+
+// Detach 捕获ctx中的数据，但不随ctx一起取消，用于请求结束后还要继续执行的后台任务
+func Detach(ctx context.Context) *Carrier {
+	c := Capture(ctx)
+	c.ctx = context.WithoutCancel(c.ctx)
+	return c
+}
+
+// Context 捕获的上下文
+func (c *Carrier) Context() context.Context {
+	return c.ctx
+}
+
+// Go 用捕获的上下文异步执行任务
+func (c *Carrier) Go(task func(ctx context.Context)) {
+	ctx := c.ctx
+	GoAsync(func(params ...any) {
+		task(ctx)
+	})
+}
+
+// Submit 用捕获的上下文将任务提交到协程池
+func (c *Carrier) Submit(p *Pool, task func(ctx context.Context)) error {
+	ctx := c.ctx
+	return p.Submit(func() {
+		task(ctx)
+	})
+}
+
+// GoCtx 异步执行任务，并将ctx显式传给任务
+func GoCtx(ctx context.Context, task func(ctx context.Context)) {
+	Capture(ctx).Go(task)
+}
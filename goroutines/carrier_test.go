@@ -0,0 +1,38 @@
+package goroutines_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestCarrier(t *testing.T) {
+	ctx := goroutines.WithBaggage(context.Background(), goroutines.Baggage{LogId: "log-1", Extend: map[string]string{"a": "1"}})
+	ctx = goroutines.WithBaggage(ctx, goroutines.Baggage{UserId: "user-1", Extend: map[string]string{"b": "2"}})
+	baggage, ok := goroutines.BaggageFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, goroutines.Baggage{LogId: "log-1", UserId: "user-1", Extend: map[string]string{"a": "1", "b": "2"}}, baggage)
+
+	ctx, cancel := context.WithCancel(ctx)
+	detached := goroutines.Detach(ctx)
+	cancel()
+
+	got := make(chan context.Context, 1)
+	detached.Go(func(ctx context.Context) {
+		got <- ctx
+	})
+	taskCtx := <-got
+	require.Nil(t, taskCtx.Err())
+	baggage, _ = goroutines.BaggageFromContext(taskCtx)
+	require.Equal(t, "log-1", baggage.LogId)
+
+	p, err := goroutines.NewPool("carrier-pool", 1, &goroutines.PoolOptions{DisableCleaner: true})
+	require.Nil(t, err)
+	defer p.Stop()
+	require.Nil(t, goroutines.Capture(ctx).Submit(p, func(ctx context.Context) {
+		got <- ctx
+	}))
+	require.NotNil(t, (<-got).Err())
+}
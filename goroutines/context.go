@@ -4,12 +4,8 @@ import (
 	"context"
 	"fmt"
 	gocache "github.com/patrickmn/go-cache"
-	"github.com/tianlin0/go-plat-utils/conv"
 	"github.com/timandy/routine"
-	"regexp"
-	"runtime"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -35,32 +31,14 @@ func getInitCache() *gocache.Cache {
 	return ctxCache
 }
 
-func getCurrentGoIdFromRuntime() int64 {
-	var buf [64]byte
-	// 获取当前 goroutine 的栈信息
-	_ = runtime.Stack(buf[:], false)
-	firstLine := strings.Split(string(buf[:]), "\n")[0]
-	re := regexp.MustCompile(`\d+`)
-	numbers := re.FindAllString(firstLine, -1)
-	if len(numbers) > 0 {
-		// 不知道是哪个数字，就进行组合起来，必然会一致的，并且会不同
-		if goIdTemp, ok := conv.Int64(strings.Join(numbers, "")); ok {
-			return goIdTemp
-		}
-	}
-	return -1
-}
-
 // getCurrentGoId 取得当前的协程ID
 func getCurrentGoId() string {
-	goId := getCurrentGoIdFromRuntime()
-	if goId > 0 {
-		return strconv.FormatInt(goId, baseInt)
-	}
 	return strconv.FormatInt(routine.Goid(), baseInt)
 }
 
 // InitContext 设置上下文，需要在入口协程上执行，会返回当前的IdKey
+//
+// Deprecated: 按协程保存上下文，子协程需要继承入口协程的ID，请使用 Capture/GoCtx 显式传递上下文
 func InitContext(ctx ...*context.Context) {
 	ctxFactory := getInitCache()
 	ctxKey := getCurrentGoId()
@@ -71,10 +49,12 @@ func InitContext(ctx ...*context.Context) {
 }
 
 // SetContext 设置上下文
+//
+// Deprecated: 请使用 Capture/GoCtx 显式传递上下文
 func SetContext(ctx *context.Context) {
 	_, ctxKey, _ := GetContext()
 	if ctxKey == "" {
-		ctxKey = getCurrentGoId() //会取到子协程的ID，所以这里会有问题
+		ctxKey = getCurrentGoId()
 	}
 	ctxFactory := getInitCache()
 	ctxFactory.Set(ctxKey, ctx, expiration)
@@ -84,6 +64,8 @@ func SetContext(ctx *context.Context) {
 }
 
 // GetContext 获取上下文
+//
+// Deprecated: 请将ctx作为参数传递，异步任务使用 Capture/GoCtx
 func GetContext() (ctx *context.Context, ctxKey string, err error) {
 	ctxFactory := getInitCache()
 
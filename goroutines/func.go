@@ -97,6 +97,9 @@ func (c *callFun) GetAll(result ...interface{}) error {
 	return nil
 }
 
+// NewCallFunc 通过反射调用方法
+//
+// Deprecated: 需要异步取得返回值时请使用 Async
 func NewCallFunc() *callFun {
 	return new(callFun)
 }
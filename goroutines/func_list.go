@@ -1,7 +1,8 @@
 package goroutines
 
 import (
-	"sync"
+	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,78 +21,60 @@ func AsyncExecuteFuncList(timeout time.Duration, calls ...func() (bool, error))
 	return AsyncExecuteDataList(timeout, dataList, callback)
 }
 
-// AsyncExecuteDataList 异步执行数据列表，返回是否全部执行
+// defaultDataListLimit AsyncExecuteDataList 的默认并发数
+const defaultDataListLimit = 50
+
+// AsyncExecuteDataList 异步执行数据列表，最多50个并发，返回是否全部执行
 // return: bool 是否完成循环。  error  执行过程中是否有错误
 func AsyncExecuteDataList[T any](timeout time.Duration, dataList []T,
 	callback func(key int, value T) (breakFlag bool, err error)) (complete bool, errExec error) {
-	if dataList == nil || len(dataList) == 0 {
+	return AsyncExecuteDataListLimit(timeout, defaultDataListLimit, dataList, callback)
+}
+
+// AsyncExecuteDataListLimit 异步执行数据列表，limit 为最大并发数，<=0 为不限
+// callback 返回 breakFlag 后，还未开始的数据不再执行；多个错误会合并返回；超时返回 false，已开始的任务会继续执行完
+func AsyncExecuteDataListLimit[T any](timeout time.Duration, limit int, dataList []T,
+	callback func(key int, value T) (breakFlag bool, err error)) (complete bool, errExec error) {
+	if len(dataList) == 0 {
 		return true, nil
 	}
-	waitGroupTemp := newWaitGroup(timeout)
-	waitGroupTemp.add(len(dataList))
 
-	breakDataList := false
-	var errTotal error
-
-	//如果dataList太长，这样会并发很多也不合理，所以分为二维数组会更合理一些，每50一组
-	pageSize := 50
-outLoop:
-	for i := 0; i < len(dataList); {
-		aw := sync.WaitGroup{}
-		if len(dataList)-i > pageSize {
-			aw.Add(pageSize)
-		} else {
-			aw.Add(len(dataList) - i)
-		}
-		for j := 0; j < pageSize; j++ {
-			if i >= len(dataList) {
-				break outLoop
-			}
-			if breakDataList {
-				//如果循环中有跳出的指令以后，则后续的循环都直接全部完成
-				waitGroupTemp.done()
-				i++
-				aw.Done()
-				continue
+	var breakDataList atomic.Bool
+	g := NewGroup().SetLimit(limit).CollectAll()
+	finished := make(chan error, 1)
+	// 分发和等待的协程不占用协程池，每条数据在池中执行
+	goDirect(func(params ...any) {
+		for i := range dataList {
+			if breakDataList.Load() {
+				break
 			}
-			GoAsync(func(params ...interface{}) {
-				oneIndexTemp, ok0 := params[0].(int)
-				oneValTemp, ok1 := params[1].(T)
-				if ok0 && ok1 {
-					if breakDataList {
-						//如果循环中有跳出的指令以后，则后续的循环都直接全部完成
-						waitGroupTemp.done()
-						aw.Done()
-						return
-					}
-
-					breakFlag, err := callback(oneIndexTemp, oneValTemp)
-					if err != nil {
-						errTotal = err
-					}
-					if breakFlag {
-						//表示需要跳出后续循环
-						breakDataList = true
-					}
-					//如果完成了，才能关闭
-					waitGroupTemp.done()
+			index, value := i, dataList[i]
+			g.Go(func() error {
+				//如果循环中有跳出的指令以后，则后续的循环都不再执行
+				if breakDataList.Load() {
+					return nil
 				}
-				aw.Done() //无论是否真正完成，都标记完成
-			}, i, dataList[i])
-
-			i++
+				breakFlag, err := callback(index, value)
+				if breakFlag {
+					breakDataList.Store(true)
+				}
+				return err
+			})
 		}
-		aw.Wait()
-	}
+		finished <- g.Wait()
+	})
 
-	err := waitGroupTemp.wait()
-	if err != nil {
-		//因为超时没有完成
-		return false, err
+	if timeout <= 0 {
+		return true, <-finished
 	}
-	//完成，但有执行的错误
-	if errTotal != nil {
-		return true, errTotal
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-finished:
+		//完成，可能有执行的错误
+		return true, err
+	case <-timer.C:
+		//因为超时没有完成
+		return false, fmt.Errorf("timeout exec over")
 	}
-	return true, nil
 }
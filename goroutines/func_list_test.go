@@ -5,6 +5,7 @@ import (
 	"github.com/tianlin0/go-plat-utils/goroutines"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -14,14 +15,14 @@ func TestAsyncExecuteDataList(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		arr = append(arr, i+1)
 	}
-	num := 0
+	var num int32
 	ret, err := goroutines.AsyncExecuteDataList(1*time.Second, arr, func(key int, value int) (breakFlag bool, err error) {
 		fmt.Println("key=", key, "; value=", value)
-		num++
+		atomic.AddInt32(&num, 1)
 		time.Sleep(1 * time.Second)
 		return true, fmt.Errorf("3333")
 	})
-	fmt.Println(num, ret, err)
+	fmt.Println(atomic.LoadInt32(&num), ret, err)
 }
 func TestGoroutineId(t *testing.T) {
 
@@ -0,0 +1,240 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Future 异步任务的结果
+type Future[T any] struct {
+	parent context.Context // 创建时传入的ctx，Then 的任务使用
+	ctx    context.Context // 传给任务的ctx，完成或取消后结束
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+	val    T
+	err    error
+}
+
+func newFuture[T any](ctx context.Context) *Future[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	return &Future[T]{
+		parent: parent,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// resolve 设置结果，只有第一次生效
+func (f *Future[T]) resolve(val T, err error) {
+	f.once.Do(func() {
+		f.val, f.err = val, err
+		close(f.done)
+		f.cancel()
+	})
+}
+
+// Async 异步执行fn，返回 Future，fn 中的 panic 会转为错误
+func Async[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	return AsyncOn(ctx, nil, fn)
+}
+
+// AsyncOn 在协程池 p 中异步执行fn，p 为空时与 GoAsync 相同，协程池拒绝时 Future 返回该错误
+func AsyncOn[T any](ctx context.Context, p *Pool, fn func(ctx context.Context) (T, error)) *Future[T] {
+	f := newFuture[T](ctx)
+	task := func() {
+		f.run(fn)
+	}
+	if p == nil {
+		GoAsync(func(params ...any) {
+			task()
+		})
+		return f
+	}
+	if err := p.Submit(task); err != nil {
+		var zero T
+		f.resolve(zero, err)
+	}
+	return f
+}
+
+// run 执行fn并设置结果，panic 转为错误
+func (f *Future[T]) run(fn func(ctx context.Context) (T, error)) {
+	var val T
+	err := runSafe(func() error {
+		var fnErr error
+		val, fnErr = fn(f.ctx)
+		return fnErr
+	})
+	f.resolve(val, err)
+}
+
+// Done 完成时关闭的通道
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel 取消传给任务的ctx
+func (f *Future[T]) Cancel() {
+	f.cancel()
+}
+
+// Await 等待结果，ctx 结束时返回 ctx 的错误，任务会继续执行
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AwaitTimeout 最多等待 timeout，超时后取消任务
+func (f *Future[T]) AwaitTimeout(timeout time.Duration) (T, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-timer.C:
+		f.cancel()
+		var zero T
+		return zero, fmt.Errorf("goroutines: future timeout: %w", context.DeadlineExceeded)
+	}
+}
+
+// Then f 成功后异步执行 fn，f 失败时直接返回该错误。
+// 等待 f 的协程不占用全局协程池，只有 fn 在协程池中执行
+func Then[T, R any](f *Future[T], fn func(ctx context.Context, v T) (R, error)) *Future[R] {
+	out := newFuture[R](f.parent)
+	goDirect(func(params ...any) {
+		var zero R
+		val, err := f.Await(out.ctx)
+		if err != nil {
+			out.resolve(zero, err)
+			return
+		}
+		GoAsync(func(params ...any) {
+			out.run(func(ctx context.Context) (R, error) {
+				return fn(ctx, val)
+			})
+		})
+	})
+	return out
+}
+
+// All 等待全部完成，结果与 futures 的顺序一致，任何一个失败时立即返回该错误并取消其他任务
+func All[T any](ctx context.Context, futures ...*Future[T]) *Future[[]T] {
+	out := newFuture[[]T](ctx)
+	results := make([]T, len(futures))
+	if len(futures) == 0 {
+		out.resolve(results, nil)
+		return out
+	}
+
+	var mu sync.Mutex
+	remaining := len(futures)
+	for i, one := range futures {
+		index, f := i, one
+		goDirect(func(params ...any) {
+			select {
+			case <-f.done:
+			case <-out.done:
+				return
+			}
+			if f.err != nil {
+				out.resolve(nil, f.err)
+				return
+			}
+			mu.Lock()
+			results[index] = f.val
+			remaining--
+			finished := remaining == 0
+			mu.Unlock()
+			if finished {
+				out.resolve(results, nil)
+			}
+		})
+	}
+	goDirect(func(params ...any) {
+		// 完成时也会取消 out.ctx，只有失败时才取消其他任务
+		<-out.ctx.Done()
+		out.resolve(nil, out.ctx.Err())
+		if out.err != nil {
+			cancelAll(futures)
+		}
+	})
+	return out
+}
+
+// Any 返回第一个成功的结果并取消其他任务，全部失败时返回合并后的错误
+func Any[T any](ctx context.Context, futures ...*Future[T]) *Future[T] {
+	return first(ctx, futures, true)
+}
+
+// Race 返回第一个完成的结果，无论成功还是失败，并取消其他任务
+func Race[T any](ctx context.Context, futures ...*Future[T]) *Future[T] {
+	return first(ctx, futures, false)
+}
+
+func first[T any](ctx context.Context, futures []*Future[T], successOnly bool) *Future[T] {
+	out := newFuture[T](ctx)
+	if len(futures) == 0 {
+		var zero T
+		out.resolve(zero, errors.New("goroutines: no futures"))
+		return out
+	}
+
+	var mu sync.Mutex
+	errs := make([]error, len(futures))
+	remaining := len(futures)
+	for i, one := range futures {
+		index, f := i, one
+		goDirect(func(params ...any) {
+			select {
+			case <-f.done:
+			case <-out.done:
+				return
+			}
+			if f.err == nil || !successOnly {
+				out.resolve(f.val, f.err)
+				return
+			}
+			mu.Lock()
+			errs[index] = f.err
+			remaining--
+			allFailed := remaining == 0
+			mu.Unlock()
+			if allFailed {
+				var zero T
+				out.resolve(zero, errors.Join(errs...))
+			}
+		})
+	}
+	goDirect(func(params ...any) {
+		// 完成时也会取消 out.ctx
+		<-out.ctx.Done()
+		var zero T
+		out.resolve(zero, out.ctx.Err())
+		cancelAll(futures)
+	})
+	return out
+}
+
+func cancelAll[T any](futures []*Future[T]) {
+	for _, one := range futures {
+		one.cancel()
+	}
+}
@@ -0,0 +1,167 @@
+package goroutines_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func sleepValue(d time.Duration, v int, err error) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestFutureAwaitThen(t *testing.T) {
+	ctx := context.Background()
+	f := goroutines.Async(ctx, sleepValue(time.Millisecond, 21, nil))
+	s := goroutines.Then(f, func(ctx context.Context, v int) (string, error) {
+		require.Nil(t, ctx.Err())
+		return strconv.Itoa(v * 2), nil
+	})
+	ret, err := s.Await(ctx)
+	require.Nil(t, err)
+	require.Equal(t, "42", ret)
+
+	panicked := goroutines.Async(ctx, func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	_, err = panicked.Await(ctx)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "boom")
+
+	slow := goroutines.Async(ctx, sleepValue(time.Second, 1, nil))
+	_, err = slow.AwaitTimeout(10 * time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = slow.Await(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFutureAllAnyRace(t *testing.T) {
+	ctx := context.Background()
+	errBad := errors.New("bad")
+
+	all, err := goroutines.All(ctx,
+		goroutines.Async(ctx, sleepValue(20*time.Millisecond, 1, nil)),
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 2, nil)),
+	).Await(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []int{1, 2}, all)
+
+	_, err = goroutines.All(ctx,
+		goroutines.Async(ctx, sleepValue(time.Second, 1, nil)),
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 2, errBad)),
+	).AwaitTimeout(500 * time.Millisecond)
+	require.ErrorIs(t, err, errBad)
+
+	anyRet, err := goroutines.Any(ctx,
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 1, errBad)),
+		goroutines.Async(ctx, sleepValue(20*time.Millisecond, 2, nil)),
+	).Await(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 2, anyRet)
+
+	_, err = goroutines.Any(ctx,
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 1, errBad)),
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 2, errBad)),
+	).Await(ctx)
+	require.ErrorIs(t, err, errBad)
+
+	_, err = goroutines.Race(ctx,
+		goroutines.Async(ctx, sleepValue(time.Millisecond, 1, errBad)),
+		goroutines.Async(ctx, sleepValue(time.Second, 2, nil)),
+	).AwaitTimeout(500 * time.Millisecond)
+	require.ErrorIs(t, err, errBad)
+}
+
+func TestFutureOnPool(t *testing.T) {
+	p, err := goroutines.NewPool("future-pool", 1, &goroutines.PoolOptions{NonBlocking: true, DisableCleaner: true})
+	require.Nil(t, err)
+	defer p.Stop()
+
+	ctx := context.Background()
+	running := goroutines.AsyncOn(ctx, p, sleepValue(50*time.Millisecond, 1, nil))
+	_, err = goroutines.AsyncOn(ctx, p, sleepValue(0, 2, nil)).Await(ctx)
+	require.ErrorIs(t, err, goroutines.ErrPoolOverload)
+	ret, err := running.Await(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, ret)
+}
+
+func TestFutureAllWithRoutinePool(t *testing.T) {
+	// 任务占满全局协程池时，All、Race 的等待协程不能再占用协程池，否则任务永远无法完成
+	goroutines.OpenRoutinePool(2)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	ctx := context.Background()
+	gate := make(chan struct{})
+	futures := make([]*goroutines.Future[int], 0)
+	for i := 0; i < 2; i++ {
+		v := i
+		futures = append(futures, goroutines.Async(ctx, func(ctx context.Context) (int, error) {
+			<-gate
+			return v, nil
+		}))
+	}
+
+	created := make(chan [2]interface{}, 1)
+	go func() {
+		created <- [2]interface{}{goroutines.All(ctx, futures...), goroutines.Race(ctx, futures...)}
+	}()
+	var ret [2]interface{}
+	select {
+	case ret = <-created:
+	case <-time.After(5 * time.Second):
+		close(gate)
+		t.Fatal("All blocks on the full routine pool")
+	}
+	close(gate)
+
+	all, err := ret[0].(*goroutines.Future[[]int]).AwaitTimeout(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1}, all)
+	val, err := ret[1].(*goroutines.Future[int]).AwaitTimeout(5 * time.Second)
+	require.NoError(t, err)
+	require.Contains(t, []int{0, 1}, val)
+}
+
+func TestFutureThenWithRoutinePool(t *testing.T) {
+	// 上游任务占满协程池时，Then 的等待协程不占用协程池，只有 fn 在协程池中执行
+	goroutines.OpenRoutinePool(1)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	ctx := context.Background()
+	gate := make(chan struct{})
+	f := goroutines.Async(ctx, func(ctx context.Context) (int, error) {
+		<-gate
+		return 1, nil
+	})
+
+	created := make(chan *goroutines.Future[int], 1)
+	go func() {
+		next := goroutines.Then(f, func(ctx context.Context, v int) (int, error) { return v + 1, nil })
+		created <- goroutines.Then(next, func(ctx context.Context, v int) (int, error) { return v * 10, nil })
+	}()
+	var chain *goroutines.Future[int]
+	select {
+	case chain = <-created:
+	case <-time.After(5 * time.Second):
+		close(gate)
+		t.Fatal("Then blocks on the full routine pool")
+	}
+	close(gate)
+
+	val, err := chain.AwaitTimeout(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 20, val)
+}
@@ -0,0 +1,212 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Group 一组协程，等待全部完成并汇总错误，类似 errgroup
+//
+// 默认遇到第一个错误后取消 ctx，并不再执行还未开始的任务，Wait 返回第一个错误；
+// CollectAll 模式下执行全部任务，Wait 返回 errors.Join 合并的所有错误。
+// 任务中的 panic 会交给 SetDefaultPanicHandle 设置的方法，并作为错误返回。
+type Group struct {
+	cancel     context.CancelFunc
+	collectAll bool
+
+	wg   sync.WaitGroup
+	sem  chan struct{}
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup 新建一组协程
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// WithContext 新建一组协程，返回的 ctx 在第一个任务出错或 Wait 返回后取消
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit 设置最大并发数，达到后 Go 会阻塞直到有任务完成，n<=0 为不限，需要在 Go 之前调用
+func (g *Group) SetLimit(n int) *Group {
+	if n <= 0 {
+		g.sem = nil
+		return g
+	}
+	g.sem = make(chan struct{}, n)
+	return g
+}
+
+// CollectAll 出错时不取消，执行全部任务并返回所有错误
+func (g *Group) CollectAll() *Group {
+	g.collectAll = true
+	return g
+}
+
+// Go 异步执行一个任务
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn)
+}
+
+// TryGo 并发数未满时异步执行任务并返回true，否则返回false
+func (g *Group) TryGo(fn func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn)
+	return true
+}
+
+func (g *Group) start(fn func() error) {
+	g.wg.Add(1)
+	GoAsync(func(params ...any) {
+		defer g.done()
+		// 已经出错时，还未开始的任务不再执行
+		if !g.collectAll && g.failed() {
+			return
+		}
+		if err := runSafe(fn); err != nil {
+			g.addError(err)
+		}
+	})
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+func (g *Group) failed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.errs) > 0
+}
+
+func (g *Group) addError(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.errs = append(g.errs, err)
+	if !g.collectAll && len(g.errs) == 1 && g.cancel != nil {
+		g.cancel()
+	}
+}
+
+// Wait 等待全部任务完成，返回错误
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if !g.collectAll {
+		return g.errs[0]
+	}
+	return errors.Join(g.errs...)
+}
+
+// RunSafe 同步执行fn，panic 时交给 SetDefaultPanicHandle 设置的方法，并转为错误返回
+func RunSafe(fn func() error) error {
+	return runSafe(fn)
+}
+
+// runSafe 执行fn，panic 时交给全局Panic处理方法并转为错误
+func runSafe(fn func() error) (err error) {
+	defer func() {
+		if ret := recover(); ret != nil {
+			err = fmt.Errorf("goroutines: task panic: %w", handlePanic(ret))
+		}
+	}()
+	return fn()
+}
+
+// ResultGroup 收集返回值的一组协程，结果按 Go 的调用顺序排列
+type ResultGroup[T any] struct {
+	group   *Group
+	mu      sync.Mutex
+	results []T
+	skipped []bool //TryGo 未执行的任务，不出现在结果中
+}
+
+// NewResultGroup 新建收集返回值的一组协程，group 为空时使用 NewGroup()
+func NewResultGroup[T any](group *Group) *ResultGroup[T] {
+	if group == nil {
+		group = NewGroup()
+	}
+	return &ResultGroup[T]{group: group}
+}
+
+// Go 异步执行一个任务，返回值保存在调用顺序对应的位置
+func (r *ResultGroup[T]) Go(fn func() (T, error)) {
+	r.group.Go(r.task(r.reserve(), fn))
+}
+
+// TryGo 并发数未满时异步执行任务并返回true，否则返回false，未执行的任务不出现在结果中
+func (r *ResultGroup[T]) TryGo(fn func() (T, error)) bool {
+	index := r.reserve()
+	if r.group.TryGo(r.task(index, fn)) {
+		return true
+	}
+	r.mu.Lock()
+	r.skipped[index] = true
+	r.mu.Unlock()
+	return false
+}
+
+// reserve 按调用顺序占一个结果的位置
+func (r *ResultGroup[T]) reserve() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var zero T
+	r.results = append(r.results, zero)
+	r.skipped = append(r.skipped, false)
+	return len(r.results) - 1
+}
+
+func (r *ResultGroup[T]) task(index int, fn func() (T, error)) func() error {
+	return func() error {
+		val, err := fn()
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		r.results[index] = val
+		r.mu.Unlock()
+		return nil
+	}
+}
+
+// Wait 等待全部任务完成，返回按调用顺序排列的结果，出错或出错后未执行的任务对应零值，TryGo 返回false的任务不包含在内
+func (r *ResultGroup[T]) Wait() ([]T, error) {
+	err := r.group.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]T, 0, len(r.results))
+	for i, one := range r.results {
+		if !r.skipped[i] {
+			results = append(results, one)
+		}
+	}
+	return results, err
+}
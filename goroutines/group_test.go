@@ -0,0 +1,131 @@
+package goroutines_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestGroupCancelOnError(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := goroutines.WithContext(context.Background())
+	g.Go(func() error {
+		return errFirst
+	})
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	require.ErrorIs(t, g.Wait(), errFirst)
+	require.NotNil(t, ctx.Err())
+}
+
+func TestGroupCollectAllAndPanic(t *testing.T) {
+	var handled int32
+	goroutines.SetDefaultPanicHandle(func(err error, retRecover any) {
+		atomic.AddInt32(&handled, 1)
+	})
+	errA, errB := errors.New("a"), errors.New("b")
+	g := goroutines.NewGroup().CollectAll()
+	g.Go(func() error { return errA })
+	g.Go(func() error { return errB })
+	g.Go(func() error { panic("boom") })
+	err := g.Wait()
+	require.ErrorIs(t, err, errA)
+	require.ErrorIs(t, err, errB)
+	require.Contains(t, err.Error(), "boom")
+	require.Equal(t, int32(1), atomic.LoadInt32(&handled))
+}
+
+func TestGroupLimit(t *testing.T) {
+	var running, maxRunning int32
+	g := goroutines.NewGroup().SetLimit(3)
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&maxRunning)
+				if n <= old || atomic.CompareAndSwapInt32(&maxRunning, old, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	require.Nil(t, g.Wait())
+	require.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(3))
+}
+
+func TestResultGroupOrdered(t *testing.T) {
+	r := goroutines.NewResultGroup[int](goroutines.NewGroup().SetLimit(4))
+	for i := 0; i < 10; i++ {
+		i := i
+		r.Go(func() (int, error) {
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			return i * i, nil
+		})
+	}
+	results, err := r.Wait()
+	require.Nil(t, err)
+	require.Equal(t, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, results)
+}
+
+func TestResultGroupTryGo(t *testing.T) {
+	r := goroutines.NewResultGroup[string](goroutines.NewGroup().SetLimit(1))
+	release := make(chan struct{})
+	require.True(t, r.TryGo(func() (string, error) {
+		<-release
+		return "first", nil
+	}))
+	require.False(t, r.TryGo(func() (string, error) {
+		return "skipped", nil
+	}))
+	close(release)
+	r.Go(func() (string, error) {
+		return "last", nil
+	})
+	results, err := r.Wait()
+	require.Nil(t, err)
+	require.Equal(t, []string{"first", "last"}, results)
+}
+
+func TestAsyncExecuteDataListWithRoutinePool(t *testing.T) {
+	goroutines.OpenRoutinePool(1)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	var count int32
+	complete, err := goroutines.AsyncExecuteDataListLimit(5*time.Second, 2, []int{1, 2, 3},
+		func(key int, value int) (bool, error) {
+			atomic.AddInt32(&count, 1)
+			return false, nil
+		})
+	require.True(t, complete)
+	require.Nil(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestAsyncExecuteDataListErrors(t *testing.T) {
+	var count int32
+	complete, err := goroutines.AsyncExecuteDataListLimit(time.Second, 5, []int{1, 2, 3, 4},
+		func(key int, value int) (bool, error) {
+			atomic.AddInt32(&count, 1)
+			if value%2 == 0 {
+				return false, errors.New("even")
+			}
+			return false, nil
+		})
+	require.True(t, complete)
+	require.NotNil(t, err)
+	require.Equal(t, int32(4), atomic.LoadInt32(&count))
+}
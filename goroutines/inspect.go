@@ -0,0 +1,209 @@
+package goroutines
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoroutineInfo 一个协程的信息
+type GoroutineInfo struct {
+	Id        int64  `json:"id"`
+	State     string `json:"state"`     // 状态，如 running、chan receive、sleep
+	TopFunc   string `json:"topFunc"`   // 当前执行的方法
+	CreatedBy string `json:"createdBy"` // 创建位置，方法名和文件行号
+	Stack     string `json:"stack"`     // 完整调用栈
+}
+
+// CreatorCount 按创建位置统计的协程数
+type CreatorCount struct {
+	CreatedBy string `json:"createdBy"`
+	Count     int    `json:"count"`
+}
+
+// Snapshot 取得当前所有协程的调用栈
+func Snapshot() []GoroutineInfo {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return parseStacks(string(buf[:n]))
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// parseStacks 解析 runtime.Stack 的输出
+func parseStacks(all string) []GoroutineInfo {
+	list := make([]GoroutineInfo, 0)
+	for _, block := range strings.Split(all, "\n\n") {
+		block = strings.TrimSpace(block)
+		if !strings.HasPrefix(block, "goroutine ") {
+			continue
+		}
+		lines := strings.Split(block, "\n")
+		info := GoroutineInfo{Stack: block}
+
+		// goroutine 7 [sleep, 2 minutes]:
+		header := strings.TrimSuffix(strings.TrimPrefix(lines[0], "goroutine "), ":")
+		if i := strings.Index(header, " ["); i > 0 {
+			info.Id, _ = strconv.ParseInt(header[:i], 10, 64)
+			info.State = strings.SplitN(strings.Trim(header[i+1:], "[]"), ",", 2)[0]
+		}
+		if len(lines) > 1 {
+			info.TopFunc = funcName(lines[1])
+		}
+		for i := 1; i < len(lines); i++ {
+			if !strings.HasPrefix(lines[i], "created by ") {
+				continue
+			}
+			info.CreatedBy = strings.SplitN(strings.TrimPrefix(lines[i], "created by "), " in goroutine", 2)[0]
+			if i+1 < len(lines) {
+				info.CreatedBy += " " + fileLine(lines[i+1])
+			}
+			break
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+// funcName 去掉调用参数，如 time.Sleep(0x34630b8a000) 返回 time.Sleep
+func funcName(line string) string {
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// fileLine 去掉偏移量，如 /tmp/s.go:10 +0x1e 返回 /tmp/s.go:10
+func fileLine(line string) string {
+	return strings.SplitN(strings.TrimSpace(line), " ", 2)[0]
+}
+
+// GroupByCreator 按创建位置统计协程数，数量多的在前
+func GroupByCreator(list []GoroutineInfo) []CreatorCount {
+	countMap := make(map[string]int)
+	for _, one := range list {
+		countMap[one.CreatedBy]++
+	}
+	counts := make([]CreatorCount, 0, len(countMap))
+	for createdBy, count := range countMap {
+		counts = append(counts, CreatorCount{CreatedBy: createdBy, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].CreatedBy < counts[j].CreatedBy
+	})
+	return counts
+}
+
+// Diff 返回 after 中有而 before 中没有的协程
+func Diff(before, after []GoroutineInfo) []GoroutineInfo {
+	exists := make(map[int64]bool, len(before))
+	for _, one := range before {
+		exists[one.Id] = true
+	}
+	added := make([]GoroutineInfo, 0)
+	for _, one := range after {
+		if !exists[one.Id] {
+			added = append(added, one)
+		}
+	}
+	return added
+}
+
+// TB testing.TB 中用到的方法
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// LeakOptions 协程泄漏检查的配置
+type LeakOptions struct {
+	Timeout time.Duration // 等待协程退出的时长，默认1秒
+	Ignore  []string      // 调用栈中包含这些字符串的协程不算泄漏
+}
+
+// defaultLeakIgnore 常驻的后台协程
+var defaultLeakIgnore = []string{
+	"github.com/panjf2000/ants/v2.",
+	"github.com/patrickmn/go-cache.(*janitor).Run",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"testing.(*T).Run",
+	"testing.tRunner",
+}
+
+// CheckLeaks 记录当前的协程，返回的方法检查是否有新增未退出的协程，有则让测试失败
+//
+//	defer goroutines.CheckLeaks(t, nil)()
+func CheckLeaks(t TB, opts *LeakOptions) func() {
+	o := LeakOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Second
+	}
+	before := Snapshot()
+	return func() {
+		t.Helper()
+		leaked := FindLeaks(before, &o)
+		if len(leaked) == 0 {
+			return
+		}
+		var sb strings.Builder
+		for _, one := range GroupByCreator(leaked) {
+			sb.WriteString(fmt.Sprintf("\n  %d created by %s", one.Count, one.CreatedBy))
+		}
+		for _, one := range leaked {
+			sb.WriteString("\n\n" + one.Stack)
+		}
+		t.Errorf("goroutines: found %d leaked goroutines:%s", len(leaked), sb.String())
+	}
+}
+
+// FindLeaks 在 Timeout 内等待新增的协程退出，返回仍未退出的协程
+func FindLeaks(before []GoroutineInfo, opts *LeakOptions) []GoroutineInfo {
+	o := LeakOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	deadline := time.Now().Add(o.Timeout)
+	wait := time.Millisecond
+	for {
+		leaked := make([]GoroutineInfo, 0)
+		for _, one := range Diff(before, Snapshot()) {
+			if !ignoreLeak(one, o.Ignore) {
+				leaked = append(leaked, one)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(wait)
+		if wait < 100*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+func ignoreLeak(info GoroutineInfo, ignore []string) bool {
+	if info.State == "running" && strings.Contains(info.Stack, "goroutines.Snapshot") {
+		return true
+	}
+	for _, list := range [][]string{defaultLeakIgnore, ignore} {
+		for _, one := range list {
+			if strings.Contains(info.Stack, one) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package goroutines_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+type fakeTB struct {
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func leakOne(stop chan struct{}) {
+	go func() {
+		<-stop
+	}()
+}
+
+func TestCheckLeaks(t *testing.T) {
+	stop := make(chan struct{})
+	fake := &fakeTB{}
+	before := goroutines.Snapshot()
+	check := goroutines.CheckLeaks(fake, &goroutines.LeakOptions{Timeout: 50 * time.Millisecond})
+	leakOne(stop)
+	check()
+	require.Len(t, fake.errors, 1)
+	require.Contains(t, fake.errors[0], "1 created by github.com/tianlin0/go-plat-utils/goroutines_test.leakOne")
+
+	close(stop)
+	require.Empty(t, goroutines.FindLeaks(before, &goroutines.LeakOptions{Timeout: time.Second}))
+}
+
+func TestSnapshot(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 3; i++ {
+		leakOne(stop)
+	}
+	counts := goroutines.GroupByCreator(goroutines.Snapshot())
+	found := false
+	for _, one := range counts {
+		if strings.Contains(one.CreatedBy, "goroutines_test.leakOne") {
+			found = true
+			require.Equal(t, 3, one.Count)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestRunWithTimeoutNoLeak(t *testing.T) {
+	defer goroutines.CheckLeaks(t, nil)()
+
+	_, err := goroutines.RunWithTimeout(10*time.Millisecond, func() (int, error) {
+		time.Sleep(30 * time.Millisecond)
+		return 1, nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ret, err := goroutines.RunWithContext(context.Background(), time.Second, func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, 2, ret)
+
+	_, err = goroutines.RunWithContext(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	require.NotNil(t, err)
+}
@@ -0,0 +1,335 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ParallelMap 用 workers 个并发对 in 中的每个元素执行 fn，结果与 in 的顺序一致，
+// 遇到第一个错误时取消 ctx 并返回该错误，workers<=0 为不限并发
+func ParallelMap[T, R any](ctx context.Context, in []T, workers int,
+	fn func(ctx context.Context, v T) (R, error)) ([]R, error) {
+	g, gCtx := WithContext(ctx)
+	g.SetLimit(workers)
+
+	out := make([]R, len(in))
+	launched := 0
+	for i := range in {
+		if gCtx.Err() != nil {
+			break
+		}
+		index := i
+		g.Go(func() error {
+			ret, err := fn(gCtx, in[index])
+			if err != nil {
+				return err
+			}
+			out[index] = ret
+			return nil
+		})
+		launched++
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if launched < len(in) && ctx != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out, nil
+}
+
+// ParallelFilter 并发判断 in 中的每个元素，返回 fn 为true的元素，保持原来的顺序
+func ParallelFilter[T any](ctx context.Context, in []T, workers int,
+	fn func(ctx context.Context, v T) (bool, error)) ([]T, error) {
+	keep, err := ParallelMap(ctx, in, workers, fn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]T, 0, len(in))
+	for i, ok := range keep {
+		if ok {
+			out = append(out, in[i])
+		}
+	}
+	return out, nil
+}
+
+// ParallelReduce 并发执行 mapFn，再按 in 的顺序用 reduceFn 合并结果
+func ParallelReduce[T, M, R any](ctx context.Context, in []T, workers int,
+	mapFn func(ctx context.Context, v T) (M, error), init R, reduceFn func(acc R, v M) R) (R, error) {
+	mapped, err := ParallelMap(ctx, in, workers, mapFn)
+	if err != nil {
+		return init, err
+	}
+	acc := init
+	for _, one := range mapped {
+		acc = reduceFn(acc, one)
+	}
+	return acc, nil
+}
+
+// Source 将 in 依次发送到返回的通道中，发送完或 ctx 结束后关闭通道
+func Source[T any](ctx context.Context, in []T) <-chan T {
+	out := make(chan T)
+	goDirect(func(params ...any) {
+		defer close(out)
+		for _, one := range in {
+			select {
+			case out <- one:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// Collect 读取通道直到关闭，返回全部数据
+func Collect[T any](in <-chan T) []T {
+	out := make([]T, 0)
+	for one := range in {
+		out = append(out, one)
+	}
+	return out
+}
+
+// StageConfig 流水线中一个阶段的配置
+type StageConfig struct {
+	Workers int  `json:"workers" yaml:"workers"` // 并发数，默认1
+	Buffer  int  `json:"buffer" yaml:"buffer"`   // 输出通道的缓冲大小，默认等于 Workers
+	Ordered bool `json:"ordered" yaml:"ordered"` // 是否按输入的顺序输出，默认按完成的顺序输出
+}
+
+// Stage 流水线中的一个阶段，用多个 worker 从 in 读取数据执行 fn，结果写入返回的通道，
+// in 需要由上游关闭；出错后取消后续执行，继续读完 in 以免上游阻塞，返回的方法在输出通道关闭后返回第一个错误
+func Stage[T, R any](ctx context.Context, in <-chan T, cfg *StageConfig,
+	fn func(ctx context.Context, v T) (R, error)) (<-chan R, func() error) {
+	c := StageConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.Buffer <= 0 {
+		c.Buffer = c.Workers
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s := newStageState(ctx)
+	out := make(chan R, c.Buffer)
+	if c.Ordered {
+		runOrderedStage(s, in, out, c, fn)
+	} else {
+		runUnorderedStage(s, in, out, c, fn)
+	}
+	return out, s.wait
+}
+
+type stageResult[R any] struct {
+	val R
+	err error
+}
+
+type stageJob[T, R any] struct {
+	val T
+	ret chan stageResult[R]
+}
+
+func runUnorderedStage[T, R any](s *stageState, in <-chan T, out chan<- R, c StageConfig,
+	fn func(ctx context.Context, v T) (R, error)) {
+	var wg sync.WaitGroup
+	wg.Add(c.Workers)
+	for i := 0; i < c.Workers; i++ {
+		goDirect(func(params ...any) {
+			defer wg.Done()
+			for v := range in {
+				if s.ctx.Err() != nil {
+					continue
+				}
+				ret, err := callStage(s.ctx, fn, v)
+				if err != nil {
+					s.fail(err)
+					continue
+				}
+				select {
+				case out <- ret:
+				case <-s.ctx.Done():
+				}
+			}
+		})
+	}
+	goDirect(func(params ...any) {
+		wg.Wait()
+		close(out)
+		s.finish()
+	})
+}
+
+func runOrderedStage[T, R any](s *stageState, in <-chan T, out chan<- R, c StageConfig,
+	fn func(ctx context.Context, v T) (R, error)) {
+	jobs := make(chan stageJob[T, R], c.Workers)
+	// queue 按输入顺序保存每个任务的结果通道，容量限制了同时处理中的任务数
+	queue := make(chan chan stageResult[R], c.Workers+c.Buffer)
+
+	goDirect(func(params ...any) {
+		defer close(jobs)
+		defer close(queue)
+		for v := range in {
+			if s.ctx.Err() != nil {
+				continue
+			}
+			ret := make(chan stageResult[R], 1)
+			queue <- ret
+			jobs <- stageJob[T, R]{val: v, ret: ret}
+		}
+	})
+	for i := 0; i < c.Workers; i++ {
+		goDirect(func(params ...any) {
+			for job := range jobs {
+				if err := s.ctx.Err(); err != nil {
+					job.ret <- stageResult[R]{err: err}
+					continue
+				}
+				val, err := callStage(s.ctx, fn, job.val)
+				job.ret <- stageResult[R]{val: val, err: err}
+			}
+		})
+	}
+	goDirect(func(params ...any) {
+		defer s.finish()
+		defer close(out)
+		for ret := range queue {
+			one := <-ret
+			if one.err != nil {
+				s.fail(one.err)
+				continue
+			}
+			if s.ctx.Err() != nil {
+				continue
+			}
+			select {
+			case out <- one.val:
+			case <-s.ctx.Done():
+			}
+		}
+	})
+}
+
+// callStage 在全局协程池中执行 fn，panic 时转为错误；worker 本身不占用协程池，只等待结果
+func callStage[T, R any](ctx context.Context, fn func(ctx context.Context, v T) (R, error), v T) (ret R, err error) {
+	runInPool(func() {
+		err = runSafe(func() error {
+			var fnErr error
+			ret, fnErr = fn(ctx, v)
+			return fnErr
+		})
+	})
+	return ret, err
+}
+
+// stageState 一个阶段的运行状态
+type stageState struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func newStageState(parent context.Context) *stageState {
+	ctx, cancel := context.WithCancel(parent)
+	return &stageState{
+		parent: parent,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// fail 记录第一个错误并取消后续执行
+func (s *stageState) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.cancel()
+}
+
+func (s *stageState) finish() {
+	if err := s.parent.Err(); err != nil {
+		s.fail(err)
+	}
+	s.cancel()
+	close(s.done)
+}
+
+func (s *stageState) wait() error {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Batch 将 in 中的数据按 size 个一批输出，maxWait>0 时一批中第一个数据等待超过 maxWait 也会输出，
+// in 关闭后输出剩余数据并关闭返回的通道
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 {
+		size = 1
+	}
+	out := make(chan []T)
+	goDirect(func(params ...any) {
+		defer close(out)
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		batch := make([]T, 0, size)
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = make([]T, 0, size)
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeout = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-timeout:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,171 @@
+package goroutines_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestParallelMapFilterReduce(t *testing.T) {
+	ctx := context.Background()
+	in := []int{1, 2, 3, 4, 5, 6}
+	squares, err := goroutines.ParallelMap(ctx, in, 3, func(ctx context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(7-v) * time.Millisecond)
+		return v * v, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, []int{1, 4, 9, 16, 25, 36}, squares)
+
+	even, err := goroutines.ParallelFilter(ctx, in, 2, func(ctx context.Context, v int) (bool, error) {
+		return v%2 == 0, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, []int{2, 4, 6}, even)
+
+	sum, err := goroutines.ParallelReduce(ctx, in, 0, func(ctx context.Context, v int) (int, error) {
+		return v * 10, nil
+	}, 0, func(acc int, v int) int {
+		return acc + v
+	})
+	require.Nil(t, err)
+	require.Equal(t, 210, sum)
+
+	errBad := errors.New("bad")
+	_, err = goroutines.ParallelMap(ctx, in, 2, func(ctx context.Context, v int) (int, error) {
+		if v == 3 {
+			return 0, errBad
+		}
+		return v, nil
+	})
+	require.ErrorIs(t, err, errBad)
+}
+
+func TestStage(t *testing.T) {
+	ctx := context.Background()
+	in := make([]int, 50)
+	for i := range in {
+		in[i] = i
+	}
+	double := func(ctx context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(v%5) * time.Millisecond)
+		return v * 2, nil
+	}
+
+	out, wait := goroutines.Stage(ctx, goroutines.Source(ctx, in), &goroutines.StageConfig{Workers: 4, Ordered: true}, double)
+	ordered := goroutines.Collect(out)
+	require.Nil(t, wait())
+	for i, v := range ordered {
+		require.Equal(t, i*2, v)
+	}
+
+	out, wait = goroutines.Stage(ctx, goroutines.Source(ctx, in), &goroutines.StageConfig{Workers: 4}, double)
+	unordered := goroutines.Collect(out)
+	require.Nil(t, wait())
+	sort.Ints(unordered)
+	require.Equal(t, ordered, unordered)
+
+	errBad := errors.New("bad")
+	out, wait = goroutines.Stage(ctx, goroutines.Source(ctx, in), &goroutines.StageConfig{Workers: 2, Ordered: true},
+		func(ctx context.Context, v int) (int, error) {
+			if v == 10 {
+				return 0, errBad
+			}
+			return v, nil
+		})
+	require.LessOrEqual(t, len(goroutines.Collect(out)), 10)
+	require.ErrorIs(t, wait(), errBad)
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	batches := goroutines.Collect(goroutines.Batch(ctx, goroutines.Source(ctx, []int{1, 2, 3, 4, 5}), 2, 0))
+	require.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, batches)
+
+	in := make(chan int)
+	out := goroutines.Batch(ctx, in, 10, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	select {
+	case batch := <-out:
+		require.Equal(t, []int{1, 2}, batch)
+	case <-time.After(time.Second):
+		t.Fatal("batch not flushed by time")
+	}
+	close(in)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestStageWithRoutinePool(t *testing.T) {
+	// 流水线的各级不占用全局协程池，协程池比 worker 少时也不会互相等待
+	goroutines.OpenRoutinePool(3)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	ctx := context.Background()
+	in := make([]int, 100)
+	for i := range in {
+		in[i] = i
+	}
+	double := func(ctx context.Context, v int) (int, error) { return v * 2, nil }
+
+	for _, ordered := range []bool{false, true} {
+		done := make(chan []int, 1)
+		go func() {
+			out, wait := goroutines.Stage(ctx, goroutines.Source(ctx, in), &goroutines.StageConfig{Workers: 4, Ordered: ordered}, double)
+			batches := goroutines.Batch(ctx, out, 10, time.Second)
+			ret := make([]int, 0, len(in))
+			for one := range batches {
+				ret = append(ret, one...)
+			}
+			require.NoError(t, wait())
+			done <- ret
+		}()
+		select {
+		case ret := <-done:
+			require.Len(t, ret, len(in))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pipeline hangs with routine pool, ordered: %v", ordered)
+		}
+	}
+}
+
+func TestStageRunsOnRoutinePool(t *testing.T) {
+	pool := goroutines.OpenRoutinePool(2)
+	t.Cleanup(goroutines.CloseRoutinePool)
+
+	var running, maxRunning, onPool int32
+	fn := func(ctx context.Context, v int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			old := atomic.LoadInt32(&maxRunning)
+			if n <= old || atomic.CompareAndSwapInt32(&maxRunning, old, n) {
+				break
+			}
+		}
+		if pool.Running() > 0 {
+			atomic.AddInt32(&onPool, 1)
+		}
+		time.Sleep(time.Millisecond)
+		return v, nil
+	}
+
+	ctx := context.Background()
+	in := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	for _, ordered := range []bool{false, true} {
+		atomic.StoreInt32(&maxRunning, 0)
+		atomic.StoreInt32(&onPool, 0)
+		out, wait := goroutines.Stage(ctx, goroutines.Source(ctx, in), &goroutines.StageConfig{Workers: 4, Ordered: ordered}, fn)
+		require.Len(t, goroutines.Collect(out), len(in))
+		require.NoError(t, wait())
+		// 4个 worker 共用2个协程池的 worker 执行 fn
+		require.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+		require.Equal(t, int32(len(in)), atomic.LoadInt32(&onPool))
+	}
+}
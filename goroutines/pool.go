@@ -0,0 +1,203 @@
+package goroutines
+
+import (
+	"errors"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+	"github.com/tianlin0/go-plat-utils/cleaner"
+	"github.com/timandy/routine"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrPoolOverload 协程池已满，任务被拒绝
+	ErrPoolOverload = errors.New("goroutines: pool overload")
+	// ErrPoolClosed 协程池已关闭
+	ErrPoolClosed = errors.New("goroutines: pool closed")
+)
+
+// PoolOptions 协程池的配置
+type PoolOptions struct {
+	MaxWaiting     int           `json:"maxWaiting" yaml:"maxWaiting"`         // 池满时最多等待的任务数，超过后拒绝，0为不限
+	NonBlocking    bool          `json:"nonBlocking" yaml:"nonBlocking"`       // 池满时不等待，直接拒绝
+	ExpiryDuration time.Duration `json:"expiryDuration" yaml:"expiryDuration"` // 空闲协程的回收时间，默认1秒
+	DrainTimeout   time.Duration `json:"drainTimeout" yaml:"drainTimeout"`     // 关闭时等待任务执行完的时长，默认10秒
+	DisableCleaner bool          `json:"disableCleaner" yaml:"disableCleaner"` // 不注册到 cleaner，需要自己调用 Stop
+
+	// PanicHandler 任务panic时的处理方法，为空时使用 SetDefaultPanicHandle 设置的方法
+	PanicHandler func(err error, retRecover any) `json:"-" yaml:"-"`
+}
+
+// PoolStats 协程池的统计
+type PoolStats struct {
+	Name      string `json:"name"`
+	Capacity  int    `json:"capacity"`
+	Running   int    `json:"running"`
+	Waiting   int    `json:"waiting"`
+	Completed int64  `json:"completed"`
+	Rejected  int64  `json:"rejected"`
+	Panics    int64  `json:"panics"`
+}
+
+// Pool 命名的协程池，不同的池互相隔离，避免后台任务占满请求处理的协程
+type Pool struct {
+	name string
+	opts PoolOptions
+	pool *ants.Pool
+
+	completed atomic.Int64
+	rejected  atomic.Int64
+	panics    atomic.Int64
+}
+
+var (
+	poolsMu sync.RWMutex
+	pools   = make(map[string]*Pool)
+)
+
+// NewPool 新建命名的协程池，size<=0 时使用 ants.DefaultAntsPoolSize，同名的池只能有一个
+func NewPool(name string, size int, opts *PoolOptions) (*Pool, error) {
+	o := PoolOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.DrainTimeout <= 0 {
+		o.DrainTimeout = 10 * time.Second
+	}
+	if size <= 0 {
+		size = ants.DefaultAntsPoolSize
+	}
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if _, ok := pools[name]; ok {
+		return nil, fmt.Errorf("goroutines: pool %s already exists", name)
+	}
+
+	antsOpts := []ants.Option{
+		ants.WithMaxBlockingTasks(o.MaxWaiting),
+		ants.WithNonblocking(o.NonBlocking),
+	}
+	if o.ExpiryDuration > 0 {
+		antsOpts = append(antsOpts, ants.WithExpiryDuration(o.ExpiryDuration))
+	}
+	antsPool, err := ants.NewPool(size, antsOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pool{
+		name: name,
+		opts: o,
+		pool: antsPool,
+	}
+	pools[name] = p
+	if !o.DisableCleaner {
+		cleaner.Register(p)
+	}
+	return p, nil
+}
+
+// GetPool 根据名称取得协程池，不存在时返回nil
+func GetPool(name string) *Pool {
+	poolsMu.RLock()
+	defer poolsMu.RUnlock()
+	return pools[name]
+}
+
+// Name 协程池名称，实现 cleaner.Cleanable
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Submit 提交任务，池满并且不能等待时返回 ErrPoolOverload，已关闭时返回 ErrPoolClosed
+func (p *Pool) Submit(task func()) error {
+	taskFun := routine.WrapTask(func() {
+		defer p.completed.Add(1)
+		defer func() {
+			if err := recover(); err != nil {
+				p.panics.Add(1)
+				p.handlePanic(err)
+			}
+		}()
+		task()
+	})
+	err := p.pool.Submit(taskFun.Run)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ants.ErrPoolOverload) {
+		p.rejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrPoolOverload, p.name)
+	}
+	if errors.Is(err, ants.ErrPoolClosed) {
+		p.rejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrPoolClosed, p.name)
+	}
+	return err
+}
+
+// Go 与 GoAsync 相同的方式提交任务
+func (p *Pool) Go(task func(params ...any), params ...any) error {
+	return p.Submit(func() {
+		task(params...)
+	})
+}
+
+func (p *Pool) handlePanic(retRecover any) {
+	if p.opts.PanicHandler == nil {
+		handlePanic(retRecover)
+		return
+	}
+	p.opts.PanicHandler(panicError(retRecover), retRecover)
+}
+
+// Tune 修改协程池大小
+func (p *Pool) Tune(size int) {
+	p.pool.Tune(size)
+}
+
+// Stats 协程池的统计
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Name:      p.name,
+		Capacity:  p.pool.Cap(),
+		Running:   p.pool.Running(),
+		Waiting:   p.pool.Waiting(),
+		Completed: p.completed.Load(),
+		Rejected:  p.rejected.Load(),
+		Panics:    p.panics.Load(),
+	}
+}
+
+// Stop 不再接收新任务，等待已提交的任务执行完，最多等待 DrainTimeout，实现 cleaner.Cleanable
+func (p *Pool) Stop() {
+	poolsMu.Lock()
+	if pools[p.name] == p {
+		delete(pools, p.name)
+	}
+	poolsMu.Unlock()
+
+	if p.pool.IsClosed() {
+		return
+	}
+	if err := p.pool.ReleaseTimeout(p.opts.DrainTimeout); err != nil {
+		log.Println("goroutines: pool", p.name, "drain error:", err)
+	}
+}
+
+// AllPoolStats 所有命名协程池的统计
+func AllPoolStats() []PoolStats {
+	poolsMu.RLock()
+	defer poolsMu.RUnlock()
+	list := make([]PoolStats, 0, len(pools))
+	for _, p := range pools {
+		list = append(list, p.Stats())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,50 @@
+package goroutines_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+)
+
+func TestPool(t *testing.T) {
+	var panics int32
+	p, err := goroutines.NewPool("test-pool", 1, &goroutines.PoolOptions{
+		NonBlocking:    true,
+		DisableCleaner: true,
+		PanicHandler: func(err error, retRecover any) {
+			atomic.AddInt32(&panics, 1)
+		},
+	})
+	require.Nil(t, err)
+	require.Same(t, p, goroutines.GetPool("test-pool"))
+	_, err = goroutines.NewPool("test-pool", 1, nil)
+	require.NotNil(t, err)
+
+	release := make(chan struct{})
+	require.Nil(t, p.Submit(func() { <-release }))
+	require.ErrorIs(t, p.Submit(func() {}), goroutines.ErrPoolOverload)
+	require.Equal(t, 1, p.Stats().Running)
+	close(release)
+
+	require.Eventually(t, func() bool {
+		return p.Submit(func() { panic("boom") }) == nil
+	}, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&panics) == 1 && p.Stats().Completed == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int64(1), p.Stats().Panics)
+	require.GreaterOrEqual(t, p.Stats().Rejected, int64(1))
+
+	var done int32
+	require.Nil(t, p.Go(func(params ...any) {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&done, int32(params[0].(int)))
+	}, 1))
+	p.Stop()
+	require.Equal(t, int32(1), atomic.LoadInt32(&done))
+	require.Nil(t, goroutines.GetPool("test-pool"))
+	require.ErrorIs(t, p.Submit(func() {}), goroutines.ErrPoolClosed)
+}
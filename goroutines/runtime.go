@@ -76,33 +76,46 @@ func CloseRoutinePool() {
 
 	defaultAsyncObj.antsPool.Release()
 	defaultAsyncObj.antsPool = nil
+	// 关闭后可以重新 OpenRoutinePool
+	defaultAsyncObj.oncePool = &internal.Once{}
 }
 
 // GoSync 同步方法
 func GoSync(task func(params ...any), params ...any) {
 	defer func() {
 		if err := recover(); err != nil {
-			//打印调用栈信息
-			buf := make([]byte, 2048)
-			n := runtime.Stack(buf, false)
-			stackInfo := fmt.Sprintf("%s", buf[:n])
-			stackInfo = strings.ReplaceAll(stackInfo, "\n", "|")
-			errStr := fmt.Sprintf("panic_stack_info: %s ### %s", err, stackInfo)
-
-			defaultAsyncObj.panicMutex.RLock()
-			if defaultAsyncObj.panicHandle != nil {
-				defaultAsyncObj.panicHandle(fmt.Errorf(errStr), err)
-				defaultAsyncObj.panicMutex.RUnlock()
-			} else {
-				defaultAsyncObj.panicMutex.RUnlock()
-				log.Println(errStr)
-			}
+			handlePanic(err)
 			return
 		}
 	}()
 	task(params...)
 }
 
+// handlePanic 将recover的值和调用栈交给全局Panic处理方法，返回带调用栈的错误
+func handlePanic(retRecover any) error {
+	panicErr := panicError(retRecover)
+
+	defaultAsyncObj.panicMutex.RLock()
+	if defaultAsyncObj.panicHandle != nil {
+		defaultAsyncObj.panicHandle(panicErr, retRecover)
+		defaultAsyncObj.panicMutex.RUnlock()
+	} else {
+		defaultAsyncObj.panicMutex.RUnlock()
+		log.Println(panicErr.Error())
+	}
+	return panicErr
+}
+
+// panicError 生成带调用栈信息的错误
+func panicError(retRecover any) error {
+	//打印调用栈信息
+	buf := make([]byte, 2048)
+	n := runtime.Stack(buf, false)
+	stackInfo := fmt.Sprintf("%s", buf[:n])
+	stackInfo = strings.ReplaceAll(stackInfo, "\n", "|")
+	return fmt.Errorf("panic_stack_info: %v ### %s", retRecover, stackInfo)
+}
+
 // GoAsync 异步方法
 func GoAsync(task func(params ...any), params ...any) {
 	fun := func() {
@@ -111,11 +124,37 @@ func GoAsync(task func(params ...any), params ...any) {
 		}(params...)
 	}
 	taskFun := routine.WrapTask(fun)
+	defaultAsyncObj.poolMutex.RLock()
+	defer defaultAsyncObj.poolMutex.RUnlock()
 	if defaultAsyncObj.antsPool == nil {
 		go taskFun.Run()
 		return
 	}
-	defaultAsyncObj.poolMutex.RLock()
-	defer defaultAsyncObj.poolMutex.RUnlock()
 	defaultAsyncObj.antsPool.Submit(taskFun.Run)
 }
+
+// runInPool 在全局协程池中执行 task 并等待完成，未开启协程池时在当前协程执行
+func runInPool(task func()) {
+	done := make(chan struct{})
+	taskFun := routine.WrapTask(func() {
+		defer close(done)
+		task()
+	})
+	defaultAsyncObj.poolMutex.RLock()
+	submitted := defaultAsyncObj.antsPool != nil && defaultAsyncObj.antsPool.Submit(taskFun.Run) == nil
+	defaultAsyncObj.poolMutex.RUnlock()
+	if !submitted {
+		task()
+		return
+	}
+	<-done
+}
+
+// goDirect 与 GoAsync 相同，但总是新开协程，不占用全局协程池。
+// 用于长时间等待其他任务的协程（流水线的各级、Future 的等待），避免占满协程池后互相等待
+func goDirect(task func(params ...any), params ...any) {
+	taskFun := routine.WrapTask(func() {
+		GoSync(task, params...)
+	})
+	go taskFun.Run()
+}
@@ -1,27 +1,47 @@
 package goroutines
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
+type timeoutResult[T any] struct {
+	ret T
+	err error
+}
+
 // RunWithTimeout 执行一个方法带过期时间
 func RunWithTimeout[T any](timeout time.Duration, fun func() (T, error)) (t T, e error) {
-	result := make(chan T)
-	err := make(chan error)
+	return RunWithContext(context.Background(), timeout, func(ctx context.Context) (T, error) {
+		return fun()
+	})
+}
+
+// RunWithContext 执行一个方法带过期时间，超时或者ctx结束后取消传给fun的ctx，
+// fun 需要检查 ctx 及时退出；结果通道有缓冲，超时后 fun 返回也不会阻塞
+func RunWithContext[T any](ctx context.Context, timeout time.Duration, fun func(ctx context.Context) (T, error)) (t T, e error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
+	result := make(chan timeoutResult[T], 1)
 	// 启动一个 goroutine 来执行耗时操作
 	GoAsync(func(params ...interface{}) {
-		oneRet, oneErr := fun()
-		result <- oneRet
-		err <- oneErr
+		ret := timeoutResult[T]{err: fmt.Errorf("goroutines: task panic")}
+		defer func() {
+			result <- ret
+		}()
+		ret.ret, ret.err = fun(ctx)
 	})
 
 	// 使用 select 语句来等待结果或超时
 	select {
 	case res := <-result:
-		return res, <-err
-	case <-time.After(timeout):
-		return t, fmt.Errorf("timeout")
+		return res.ret, res.err
+	case <-ctx.Done():
+		return t, fmt.Errorf("timeout: %w", ctx.Err())
 	}
 }
@@ -0,0 +1,271 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority 请求的优先级，负载高时先丢弃低优先级的请求
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+var priorityNameMap = map[string]Priority{
+	"low":      PriorityLow,
+	"normal":   PriorityNormal,
+	"high":     PriorityHigh,
+	"critical": PriorityCritical,
+}
+
+// priorityRatio 各优先级最多可以使用的并发比例
+var priorityRatio = map[Priority]float64{
+	PriorityLow:      0.6,
+	PriorityNormal:   0.8,
+	PriorityHigh:     0.95,
+	PriorityCritical: 1,
+}
+
+// ErrShed 请求被自适应限流丢弃
+var ErrShed = errors.New("[limiter] request shed by adaptive limiter")
+
+// errPanic fn panic时上报的错误，按过载处理
+var errPanic = errors.New("[limiter] panic")
+
+type priorityCtxKey struct{}
+
+// WithPriority 在ctx中设置请求优先级
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+// PriorityFromContext 从ctx中取得请求优先级，默认 PriorityNormal
+func PriorityFromContext(ctx context.Context) Priority {
+	if ctx != nil {
+		if p, ok := ctx.Value(priorityCtxKey{}).(Priority); ok {
+			return p
+		}
+	}
+	return PriorityNormal
+}
+
+// ParsePriority 解析优先级，支持名称(low/normal/high/critical)和数字，无法识别时为 PriorityNormal
+func ParsePriority(s string) Priority {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if p, ok := priorityNameMap[s]; ok {
+		return p
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= int(PriorityLow) && n <= int(PriorityCritical) {
+		return Priority(n)
+	}
+	return PriorityNormal
+}
+
+// AdaptiveConfig 自适应并发限流的配置
+type AdaptiveConfig struct {
+	InitialLimit int `json:"initialLimit" yaml:"initialLimit"` // 初始并发上限，默认20
+	MinLimit     int `json:"minLimit" yaml:"minLimit"`         // 最小并发上限，默认1
+	MaxLimit     int `json:"maxLimit" yaml:"maxLimit"`         // 最大并发上限，默认1000
+	// TargetLatency 延迟超过该值视为过载；为0时使用最近观测到的最小延迟的 LatencyTolerance 倍
+	TargetLatency    time.Duration `json:"targetLatency" yaml:"targetLatency"`
+	LatencyTolerance float64       `json:"latencyTolerance" yaml:"latencyTolerance"` // 默认2
+	LatencyWindow    time.Duration `json:"latencyWindow" yaml:"latencyWindow"`       // 最小延迟的统计周期，默认30秒
+	BackoffRatio     float64       `json:"backoffRatio" yaml:"backoffRatio"`         // 过载时上限乘以该比例，默认0.9
+	PriorityHeader   string        `json:"priorityHeader" yaml:"priorityHeader"`     // 中间件读取优先级的请求头，默认 X-Priority
+}
+
+// AdaptiveStats 自适应限流的统计
+type AdaptiveStats struct {
+	Limit          int                 `json:"limit"`          // 当前并发上限
+	InFlight       int                 `json:"inFlight"`       // 当前正在执行的请求数
+	MinLatency     time.Duration       `json:"minLatency"`     // 观测到的最小延迟
+	Passed         uint64              `json:"passed"`         // 放行的请求数
+	Shed           uint64              `json:"shed"`           // 丢弃的请求数
+	ShedByPriority map[Priority]uint64 `json:"shedByPriority"` // 各优先级丢弃的请求数
+}
+
+// AdaptiveLimiter AIMD自适应并发限流：延迟正常时并发上限加性增长，延迟升高或超时时乘性减小
+type AdaptiveLimiter struct {
+	cfg AdaptiveConfig
+
+	mu             sync.Mutex
+	limit          float64
+	inFlight       int
+	minLatency     time.Duration // 上一个周期的最小延迟
+	windowMin      time.Duration // 当前周期的最小延迟
+	windowStart    time.Time
+	passed         uint64
+	shedByPriority map[Priority]uint64
+}
+
+// NewAdaptiveLimiter 新建自适应并发限流器
+func NewAdaptiveLimiter(cfg *AdaptiveConfig) *AdaptiveLimiter {
+	c := AdaptiveConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.MinLimit <= 0 {
+		c.MinLimit = 1
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = 1000
+	}
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = 20
+	}
+	c.InitialLimit = int(math.Min(math.Max(float64(c.InitialLimit), float64(c.MinLimit)), float64(c.MaxLimit)))
+	if c.LatencyTolerance <= 1 {
+		c.LatencyTolerance = 2
+	}
+	if c.LatencyWindow <= 0 {
+		c.LatencyWindow = 30 * time.Second
+	}
+	if c.BackoffRatio <= 0 || c.BackoffRatio >= 1 {
+		c.BackoffRatio = 0.9
+	}
+	if c.PriorityHeader == "" {
+		c.PriorityHeader = "X-Priority"
+	}
+	return &AdaptiveLimiter{
+		cfg:            c,
+		limit:          float64(c.InitialLimit),
+		windowStart:    time.Now(),
+		shedByPriority: make(map[Priority]uint64),
+	}
+}
+
+// Acquire 申请一个并发名额，成功后必须调用 release 归还，并传入执行结果的错误
+func (a *AdaptiveLimiter) Acquire(p Priority) (release func(err error), err error) {
+	ratio, ok := priorityRatio[p]
+	if !ok {
+		ratio = priorityRatio[PriorityNormal]
+	}
+
+	a.mu.Lock()
+	allowed := int(math.Max(1, math.Floor(a.limit*ratio)))
+	if a.inFlight >= allowed {
+		a.shedByPriority[p]++
+		a.mu.Unlock()
+		return nil, ErrShed
+	}
+	a.inFlight++
+	a.passed++
+	a.mu.Unlock()
+
+	start := time.Now()
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			a.release(time.Since(start), err)
+		})
+	}, nil
+}
+
+// Do 在限流保护下执行fn，优先级从ctx中读取，被丢弃时返回 ErrShed，fn panic时计为过载
+func (a *AdaptiveLimiter) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := a.Acquire(PriorityFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer releaseOnPanic(release)
+	err = fn(ctx)
+	release(err)
+	return err
+}
+
+// releaseOnPanic panic时归还名额并按过载处理，然后继续panic，避免名额一直被占用
+func releaseOnPanic(release func(err error)) {
+	if r := recover(); r != nil {
+		release(fmt.Errorf("%w: %v", errPanic, r))
+		panic(r)
+	}
+}
+
+func (a *AdaptiveLimiter) release(latency time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	inFlight := a.inFlight
+	a.inFlight--
+
+	now := time.Now()
+	if a.windowMin == 0 || latency < a.windowMin {
+		a.windowMin = latency
+	}
+	if now.Sub(a.windowStart) >= a.cfg.LatencyWindow {
+		a.minLatency = a.windowMin
+		a.windowMin = 0
+		a.windowStart = now
+	}
+	minLatency := a.minLatency
+	if minLatency == 0 || (a.windowMin > 0 && a.windowMin < minLatency) {
+		minLatency = a.windowMin
+	}
+
+	threshold := a.cfg.TargetLatency
+	if threshold <= 0 {
+		threshold = time.Duration(float64(minLatency) * a.cfg.LatencyTolerance)
+	}
+
+	overload := errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errPanic) ||
+		(threshold > 0 && latency > threshold)
+	if overload {
+		a.limit = math.Max(float64(a.cfg.MinLimit), a.limit*a.cfg.BackoffRatio)
+		return
+	}
+	// 只有并发接近上限时才增长，避免空闲时上限无限增大
+	if float64(inFlight) >= a.limit/2 {
+		a.limit = math.Min(float64(a.cfg.MaxLimit), a.limit+1/a.limit)
+	}
+}
+
+// Stats 获取统计信息
+func (a *AdaptiveLimiter) Stats() AdaptiveStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := AdaptiveStats{
+		Limit:          int(a.limit),
+		InFlight:       a.inFlight,
+		MinLatency:     a.minLatency,
+		Passed:         a.passed,
+		ShedByPriority: make(map[Priority]uint64, len(a.shedByPriority)),
+	}
+	if stats.MinLatency == 0 {
+		stats.MinLatency = a.windowMin
+	}
+	for p, n := range a.shedByPriority {
+		stats.ShedByPriority[p] = n
+		stats.Shed += n
+	}
+	return stats
+}
+
+// AdaptiveMiddleware 自适应并发限流的HTTP中间件，优先级从请求头读取，被丢弃时返回 503
+func AdaptiveMiddleware(next http.Handler, a *AdaptiveLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := ParsePriority(r.Header.Get(a.cfg.PriorityHeader))
+		release, err := a.Acquire(p)
+		if err != nil {
+			w.Header().Set(headerRetryAfter, "1")
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		// handler panic时由 net/http 恢复，这里先归还名额
+		defer releaseOnPanic(release)
+		ctx := WithPriority(r.Context(), p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+		release(ctx.Err())
+	})
+}
@@ -0,0 +1,114 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/limiter"
+)
+
+func TestAdaptiveLimiterShed(t *testing.T) {
+	al := limiter.NewAdaptiveLimiter(&limiter.AdaptiveConfig{
+		InitialLimit:  10,
+		TargetLatency: time.Second,
+	})
+
+	block := make(chan struct{})
+	var wg sync.WaitGroup
+	// 占满普通优先级可用的8个名额
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = al.Do(context.Background(), func(ctx context.Context) error {
+				<-block
+				return nil
+			})
+		}()
+	}
+	require.Eventually(t, func() bool { return al.Stats().InFlight == 8 }, time.Second, time.Millisecond)
+
+	err := al.Do(limiter.WithPriority(context.Background(), limiter.PriorityLow), func(ctx context.Context) error {
+		return nil
+	})
+	require.ErrorIs(t, err, limiter.ErrShed)
+	err = al.Do(context.Background(), func(ctx context.Context) error { return nil })
+	require.ErrorIs(t, err, limiter.ErrShed)
+	// 高优先级还可以通过
+	err = al.Do(limiter.WithPriority(context.Background(), limiter.PriorityCritical), func(ctx context.Context) error {
+		return nil
+	})
+	require.Nil(t, err)
+
+	close(block)
+	wg.Wait()
+
+	stats := al.Stats()
+	require.Equal(t, uint64(2), stats.Shed)
+	require.Equal(t, uint64(1), stats.ShedByPriority[limiter.PriorityLow])
+	require.Equal(t, 0, stats.InFlight)
+}
+
+func TestAdaptiveLimiterBackoff(t *testing.T) {
+	al := limiter.NewAdaptiveLimiter(&limiter.AdaptiveConfig{
+		InitialLimit:  10,
+		TargetLatency: 5 * time.Millisecond,
+	})
+	for i := 0; i < 5; i++ {
+		_ = al.Do(context.Background(), func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	}
+	require.Less(t, al.Stats().Limit, 10)
+}
+
+func TestAdaptiveLimiterPanic(t *testing.T) {
+	al := limiter.NewAdaptiveLimiter(&limiter.AdaptiveConfig{
+		InitialLimit:  10,
+		TargetLatency: time.Second,
+	})
+	for i := 0; i < 20; i++ {
+		require.Panics(t, func() {
+			_ = al.Do(context.Background(), func(ctx context.Context) error { panic("boom") })
+		})
+	}
+	// panic后名额已归还，并按过载降低了上限
+	stats := al.Stats()
+	require.Equal(t, 0, stats.InFlight)
+	require.Less(t, stats.Limit, 10)
+	require.Nil(t, al.Do(context.Background(), func(ctx context.Context) error { return nil }))
+
+	handler := limiter.AdaptiveMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), al)
+	require.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	require.Equal(t, 0, al.Stats().InFlight)
+}
+
+func TestAdaptiveMiddleware(t *testing.T) {
+	al := limiter.NewAdaptiveLimiter(&limiter.AdaptiveConfig{InitialLimit: 1})
+	var handler http.Handler
+	handler = limiter.AdaptiveMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, limiter.PriorityHigh, limiter.PriorityFromContext(r.Context()))
+		// 并发已满，嵌套的请求会被丢弃
+		inner := httptest.NewRecorder()
+		handler.ServeHTTP(inner, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusServiceUnavailable, inner.Code)
+		w.WriteHeader(http.StatusOK)
+	}), al)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Priority", "high")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, uint64(1), al.Stats().Shed)
+}
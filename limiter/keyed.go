@@ -0,0 +1,137 @@
+package limiter
+
+import (
+	"context"
+	"github.com/tianlin0/go-plat-utils/cache"
+	"golang.org/x/time/rate"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	defaultKeyedMaxKeys     = 10000
+	defaultKeyedIdleTimeout = 10 * time.Minute
+)
+
+// KeyedConfig 按key限流的配置，可以直接从配置文件加载
+type KeyedConfig struct {
+	Default     Rate            `json:"default" yaml:"default"`         // 默认速率
+	Overrides   map[string]Rate `json:"overrides" yaml:"overrides"`     // 指定key的速率，如 "ip:127.0.0.1"
+	MaxKeys     int             `json:"maxKeys" yaml:"maxKeys"`         // 最多保存的key数量，超过后淘汰最久未使用的
+	IdleTimeout time.Duration   `json:"idleTimeout" yaml:"idleTimeout"` // key闲置多久后淘汰
+}
+
+// keyedEntry 一个key对应的限流器，rate 用于判断配置是否变更
+type keyedEntry struct {
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	rate    Rate
+}
+
+// KeyedLimiter 进程内按key（IP、API Key、租户等）独立限流，不同key之间互不影响
+type KeyedLimiter struct {
+	cfgMu     sync.RWMutex
+	def       Rate
+	overrides map[string]Rate
+
+	createMu sync.Mutex
+	limiters cache.CommCache[*keyedEntry]
+}
+
+// NewKeyedLimiter 新建按key限流的限流器
+func NewKeyedLimiter(cfg *KeyedConfig) *KeyedLimiter {
+	if cfg == nil {
+		cfg = new(KeyedConfig)
+	}
+	maxKeys := cfg.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultKeyedMaxKeys
+	}
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultKeyedIdleTimeout
+	}
+
+	k := &KeyedLimiter{
+		limiters: cache.NewMemLruCache[*keyedEntry](maxKeys, idleTimeout),
+	}
+	k.SetRates(cfg.Default, cfg.Overrides)
+	return k
+}
+
+// SetRates 运行时更新默认速率和指定key的速率，已存在的限流器在下次使用时生效
+func (k *KeyedLimiter) SetRates(def Rate, overrides map[string]Rate) {
+	newOverrides := make(map[string]Rate, len(overrides))
+	for key, r := range overrides {
+		newOverrides[key] = r.normalize()
+	}
+
+	k.cfgMu.Lock()
+	defer k.cfgMu.Unlock()
+	k.def = def.normalize()
+	k.overrides = newOverrides
+}
+
+// RateOf 获取key当前使用的速率
+func (k *KeyedLimiter) RateOf(key string) Rate {
+	k.cfgMu.RLock()
+	defer k.cfgMu.RUnlock()
+	if r, ok := k.overrides[key]; ok {
+		return r
+	}
+	return k.def
+}
+
+// getLimiter 获取或新建key对应的限流器，每次访问都会刷新闲置时间
+func (k *KeyedLimiter) getLimiter(ctx context.Context, key string) *rate.Limiter {
+	r := k.RateOf(key)
+
+	entry, _ := k.limiters.Get(ctx, key)
+	if entry == nil {
+		k.createMu.Lock()
+		entry, _ = k.limiters.Get(ctx, key)
+		if entry == nil {
+			entry = &keyedEntry{limiter: newRateLimiter(r), rate: r}
+		}
+		_, _ = k.limiters.Set(ctx, key, entry, 0)
+		k.createMu.Unlock()
+	} else {
+		_, _ = k.limiters.Set(ctx, key, entry, 0)
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.rate != r {
+		// 配置有变更，保留已有的令牌，只调整速率和容量
+		entry.limiter.SetLimit(rate.Limit(float64(r.Limit) / r.Period.Seconds()))
+		entry.limiter.SetBurst(r.Burst)
+		entry.rate = r
+	}
+	return entry.limiter
+}
+
+// Allow 是否可以立即通过1个请求，方便非HTTP场景（如队列消费）使用
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.getLimiter(context.Background(), key).Allow()
+}
+
+// AllowN 是否可以立即通过
+func (k *KeyedLimiter) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	return allowLocal(k.getLimiter(ctx, key), n), nil
+}
+
+// ReserveN 预订配额
+func (k *KeyedLimiter) ReserveN(ctx context.Context, key string, n int) (*Result, error) {
+	return reserveLocal(k.getLimiter(ctx, key), n), nil
+}
+
+// WaitN 等待直到可以通过
+func (k *KeyedLimiter) WaitN(ctx context.Context, key string, n int) error {
+	return k.getLimiter(ctx, key).WaitN(ctx, n)
+}
+
+// KeyedRateLimitMiddleware 进程内按key限流的HTTP中间件，默认按客户端IP
+func KeyedRateLimitMiddleware(next http.Handler, cfg *KeyedConfig, mwCfg *MiddlewareConfig) http.Handler {
+	return LimiterMiddleware(next, NewKeyedLimiter(cfg), mwCfg)
+}
@@ -0,0 +1,93 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Limiter 限流器的通用接口，本地和redis的各种算法均实现了该接口
+type Limiter interface {
+	// AllowN 判断key是否可以立即通过n个请求，被拒绝时不消耗配额
+	AllowN(ctx context.Context, key string, n int) (*Result, error)
+	// ReserveN 预订n个配额，Result.RetryAfter 为使用前需要等待的时间，
+	// 不支持预订的算法（固定窗口、滑动窗口）与 AllowN 相同
+	ReserveN(ctx context.Context, key string, n int) (*Result, error)
+	// WaitN 阻塞直到可以通过n个请求，或者ctx结束
+	WaitN(ctx context.Context, key string, n int) error
+}
+
+// Rate 限流速率，Period 内允许 Limit 次
+type Rate struct {
+	Limit  int           `json:"limit" yaml:"limit"`   // 周期内允许的次数
+	Period time.Duration `json:"period" yaml:"period"` // 周期，默认1秒
+	Burst  int           `json:"burst" yaml:"burst"`   // 突发容量，GCRA和令牌桶使用，默认等于Limit
+}
+
+// PerSecond 每秒n次
+func PerSecond(n int) Rate {
+	return Rate{Limit: n, Period: time.Second, Burst: n}
+}
+
+// PerMinute 每分钟n次
+func PerMinute(n int) Rate {
+	return Rate{Limit: n, Period: time.Minute, Burst: n}
+}
+
+// normalize 补全默认值
+func (r Rate) normalize() Rate {
+	if r.Period <= 0 {
+		r.Period = time.Second
+	}
+	if r.Limit <= 0 {
+		r.Limit = 1
+	}
+	if r.Burst <= 0 {
+		r.Burst = r.Limit
+	}
+	return r
+}
+
+// LimiterMiddleware 使用任意 Limiter 的HTTP限流中间件，每个请求消耗1个配额
+func LimiterMiddleware(next http.Handler, l Limiter, cfg *MiddlewareConfig) http.Handler {
+	return rateLimitHandler(next, func(ctx context.Context, key string) (*Result, error) {
+		return l.AllowN(ctx, key, 1)
+	}, cfg)
+}
+
+// waitN 轮询 AllowN，被拒绝时按 RetryAfter 等待，不会预订未来的配额
+func waitN(ctx context.Context, l Limiter, key string, n int) error {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		ret, err := l.AllowN(ctx, key, n)
+		if err != nil {
+			return err
+		}
+		if ret.Allowed {
+			return nil
+		}
+		if ret.RetryAfter <= 0 {
+			return fmt.Errorf("[limiter] wait n: %d exceeds limit: %d", n, ret.Limit)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < ret.RetryAfter {
+			return fmt.Errorf("[limiter] wait n: %d would exceed context deadline", n)
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(ret.RetryAfter)
+		} else {
+			timer.Reset(ret.RetryAfter)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package limiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/limiter"
+)
+
+func TestLimiterAlgorithms(t *testing.T) {
+	_, cli := newRedisClient(t)
+	rate := limiter.Rate{Limit: 5, Period: time.Second, Burst: 5}
+
+	testCases := []struct {
+		name       string
+		newLimiter func(client *redis.Client, r limiter.Rate) limiter.Limiter
+	}{
+		{"gcra", limiter.NewGCRALimiter},
+		{"tokenBucket", limiter.NewTokenBucketLimiter},
+		{"fixedWindow", limiter.NewFixedWindowLimiter},
+		{"slidingWindow", limiter.NewSlidingWindowLimiter},
+		{"slidingLog", limiter.NewSlidingLogLimiter},
+		{"local", func(_ *redis.Client, r limiter.Rate) limiter.Limiter {
+			return limiter.NewLocalLimiter(r)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.newLimiter(cli, rate)
+			ctx := context.Background()
+			key := "algorithm:" + tc.name
+
+			ret, err := l.AllowN(ctx, key, 3)
+			require.Nil(t, err)
+			require.True(t, ret.Allowed)
+
+			ret, err = l.AllowN(ctx, key, 2)
+			require.Nil(t, err)
+			require.True(t, ret.Allowed)
+			require.Equal(t, 0, ret.Remaining)
+
+			ret, err = l.AllowN(ctx, key, 1)
+			require.Nil(t, err)
+			require.False(t, ret.Allowed)
+			require.Greater(t, ret.RetryAfter, time.Duration(0))
+			require.LessOrEqual(t, ret.RetryAfter, time.Second)
+
+			// 超过上限的请求永远不会被满足
+			ret, err = l.AllowN(ctx, key+":big", 6)
+			require.Nil(t, err)
+			require.False(t, ret.Allowed)
+
+			waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			require.Nil(t, l.WaitN(waitCtx, key, 1))
+		})
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	_, cli := newRedisClient(t)
+	rate := limiter.Rate{Limit: 10, Period: time.Second, Burst: 1}
+	ctx := context.Background()
+
+	for name, l := range map[string]limiter.Limiter{
+		"gcra":        limiter.NewGCRALimiter(cli, rate),
+		"tokenBucket": limiter.NewTokenBucketLimiter(cli, rate),
+		"local":       limiter.NewLocalLimiter(rate),
+	} {
+		t.Run(name, func(t *testing.T) {
+			key := "reserve:" + name
+			ret, err := l.ReserveN(ctx, key, 1)
+			require.Nil(t, err)
+			require.True(t, ret.Allowed)
+			require.Equal(t, time.Duration(0), ret.RetryAfter)
+
+			// 预订成功，但需要等待约100ms
+			ret, err = l.ReserveN(ctx, key, 1)
+			require.Nil(t, err)
+			require.True(t, ret.Allowed)
+			require.Greater(t, ret.RetryAfter, 50*time.Millisecond)
+			require.LessOrEqual(t, ret.RetryAfter, 100*time.Millisecond)
+		})
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	kl := limiter.NewKeyedLimiter(&limiter.KeyedConfig{
+		Default: limiter.Rate{Limit: 1, Period: time.Minute},
+		Overrides: map[string]limiter.Rate{
+			"tenant:vip": {Limit: 3, Period: time.Minute},
+		},
+		MaxKeys: 2,
+	})
+
+	require.True(t, kl.Allow("tenant:a"))
+	require.False(t, kl.Allow("tenant:a"))
+	// 每个key独立计算配额
+	require.True(t, kl.Allow("tenant:b"))
+
+	for i := 0; i < 3; i++ {
+		require.True(t, kl.Allow("tenant:vip"))
+	}
+	require.False(t, kl.Allow("tenant:vip"))
+
+	// 超过 MaxKeys 后最久未使用的 tenant:a 被淘汰，重新获得配额
+	require.True(t, kl.Allow("tenant:a"))
+
+	// 运行时调整配置
+	kl.SetRates(limiter.Rate{Limit: 5, Period: time.Minute}, nil)
+	require.Equal(t, 5, kl.RateOf("tenant:vip").Limit)
+	ret, err := kl.AllowN(context.Background(), "tenant:c", 5)
+	require.Nil(t, err)
+	require.True(t, ret.Allowed)
+}
@@ -0,0 +1,82 @@
+package limiter
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"time"
+)
+
+// localLimiter 基于 rate.Limiter 的进程内令牌桶，所有key共用一个桶
+type localLimiter struct {
+	limiter *rate.Limiter
+	rate    Rate
+}
+
+// NewLocalLimiter 新建进程内的令牌桶限流器，忽略key，所有请求共用配额
+func NewLocalLimiter(r Rate) Limiter {
+	r = r.normalize()
+	return &localLimiter{
+		limiter: newRateLimiter(r),
+		rate:    r,
+	}
+}
+
+func newRateLimiter(r Rate) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(float64(r.Limit)/r.Period.Seconds()), r.Burst)
+}
+
+// AllowN 是否可以立即通过
+func (l *localLimiter) AllowN(_ context.Context, _ string, n int) (*Result, error) {
+	return allowLocal(l.limiter, n), nil
+}
+
+// ReserveN 预订配额
+func (l *localLimiter) ReserveN(_ context.Context, _ string, n int) (*Result, error) {
+	return reserveLocal(l.limiter, n), nil
+}
+
+// WaitN 等待直到可以通过
+func (l *localLimiter) WaitN(ctx context.Context, _ string, n int) error {
+	return l.limiter.WaitN(ctx, n)
+}
+
+func allowLocal(limiter *rate.Limiter, n int) *Result {
+	now := time.Now()
+	ret := &Result{Limit: limiter.Burst()}
+	if limiter.AllowN(now, n) {
+		ret.Allowed = true
+	} else {
+		// 试着预订一次得到需要等待的时间，然后取消
+		reservation := limiter.ReserveN(now, n)
+		if reservation.OK() {
+			ret.RetryAfter = reservation.DelayFrom(now)
+			reservation.CancelAt(now)
+		}
+	}
+	fillLocalTokens(limiter, now, ret)
+	return ret
+}
+
+func reserveLocal(limiter *rate.Limiter, n int) *Result {
+	now := time.Now()
+	ret := &Result{Limit: limiter.Burst()}
+	reservation := limiter.ReserveN(now, n)
+	if reservation.OK() {
+		ret.Allowed = true
+		ret.RetryAfter = reservation.DelayFrom(now)
+	}
+	fillLocalTokens(limiter, now, ret)
+	return ret
+}
+
+// fillLocalTokens 根据桶内剩余的令牌计算剩余配额和恢复时间
+func fillLocalTokens(limiter *rate.Limiter, now time.Time, ret *Result) {
+	tokens := limiter.TokensAt(now)
+	if tokens > 0 {
+		ret.Remaining = int(tokens)
+	}
+	if limit := float64(limiter.Limit()); limit > 0 {
+		missing := float64(limiter.Burst()) - tokens
+		ret.ResetAfter = time.Duration(missing / limit * float64(time.Second))
+	}
+}
@@ -0,0 +1,142 @@
+package limiter
+
+import (
+	"context"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"github.com/tianlin0/go-plat-utils/utils/httputil/param"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// KeyFunc 从请求中取出限流的维度，返回空表示该请求不限流
+type KeyFunc func(r *http.Request) string
+
+// KeyByClientIP 按客户端IP限流
+func KeyByClientIP() KeyFunc {
+	return func(r *http.Request) string {
+		if ip := param.ClientIP(r); ip != "" {
+			return "ip:" + ip
+		}
+		return ""
+	}
+}
+
+// KeyByHeader 按请求头的值限流，如 X-Api-Key
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if val := r.Header.Get(name); val != "" {
+			return "header:" + name + ":" + val
+		}
+		return ""
+	}
+}
+
+// KeyByUserId 按用户ID限流，用户ID的获取方式由业务传入
+func KeyByUserId(getUserId func(r *http.Request) string) KeyFunc {
+	return func(r *http.Request) string {
+		if getUserId == nil {
+			return ""
+		}
+		if userId := getUserId(r); userId != "" {
+			return "user:" + userId
+		}
+		return ""
+	}
+}
+
+// KeyByRoute 按请求的方法和路径限流
+func KeyByRoute() KeyFunc {
+	return func(r *http.Request) string {
+		return "route:" + r.Method + ":" + r.URL.Path
+	}
+}
+
+// MiddlewareConfig 限流中间件的配置
+type MiddlewareConfig struct {
+	KeyPrefix string  // 存储key的前缀，用于区分不同业务
+	KeyFunc   KeyFunc // 限流维度，默认按客户端IP
+	FailOpen  bool    // 限流器出错时（如redis不可用）是否放行，默认拒绝
+	// OnLimited 被限流时的处理，默认返回 429
+	OnLimited func(w http.ResponseWriter, r *http.Request, ret *Result)
+}
+
+type takeFunc func(ctx context.Context, key string) (*Result, error)
+
+// RedisRateLimitMiddleware 基于redis滑动窗口的分布式限流，window 内每个key最多 limit 次
+func RedisRateLimitMiddleware(next http.Handler, rl *RedisLimiter, limit int, window time.Duration,
+	cfg *MiddlewareConfig) http.Handler {
+	return rateLimitHandler(next, func(ctx context.Context, key string) (*Result, error) {
+		return rl.Take(ctx, key, limit, window)
+	}, cfg)
+}
+
+func rateLimitHandler(next http.Handler, take takeFunc, cfg *MiddlewareConfig) http.Handler {
+	if cfg == nil {
+		cfg = new(MiddlewareConfig)
+	}
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = KeyByClientIP()
+	}
+	onLimited := cfg.OnLimited
+	if onLimited == nil {
+		onLimited = defaultOnLimited
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := keyFunc(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ret, err := take(r.Context(), cfg.KeyPrefix+key)
+		if err != nil {
+			logs.CtxLogger(r.Context()).Error("[limiter] take error:", key, err.Error())
+			if cfg.FailOpen {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		setRateLimitHeader(w, ret)
+		if !ret.Allowed {
+			onLimited(w, r, ret)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func defaultOnLimited(w http.ResponseWriter, _ *http.Request, _ *Result) {
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
+
+// setRateLimitHeader 输出标准的限流响应头，时间均为秒
+func setRateLimitHeader(w http.ResponseWriter, ret *Result) {
+	header := w.Header()
+	header.Set(headerRateLimitLimit, strconv.Itoa(ret.Limit))
+	header.Set(headerRateLimitRemaining, strconv.Itoa(ret.Remaining))
+	header.Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(ret.ResetAfter)))
+	if !ret.Allowed {
+		header.Set(headerRetryAfter, strconv.Itoa(ceilSeconds(ret.RetryAfter)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,92 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/limiter"
+)
+
+func newRedisClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	s := miniredis.RunT(t)
+	return s, redis.NewClient(&redis.Options{Addr: s.Addr()})
+}
+
+func TestRedisLimiterTake(t *testing.T) {
+	_, cli := newRedisClient(t)
+	rl := limiter.NewRedisLimiter(cli)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		ret, err := rl.Take(ctx, "take", 3, time.Minute)
+		require.Nil(t, err)
+		require.True(t, ret.Allowed)
+		require.Equal(t, 2-i, ret.Remaining)
+	}
+	ret, err := rl.Take(ctx, "take", 3, time.Minute)
+	require.Nil(t, err)
+	require.False(t, ret.Allowed)
+	require.Greater(t, ret.RetryAfter, 50*time.Second)
+}
+
+func TestRedisRateLimitMiddleware(t *testing.T) {
+	_, cli := newRedisClient(t)
+	handler := limiter.RedisRateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), limiter.NewRedisLimiter(cli), 2, time.Minute, &limiter.MiddlewareConfig{
+		KeyPrefix: "test:",
+		KeyFunc:   limiter.KeyByHeader("X-Api-Key"),
+	})
+
+	serve := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		req.Header.Set("X-Api-Key", apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve("a").Code)
+	w := serve("a")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = serve("a")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 不同的key互不影响
+	require.Equal(t, http.StatusOK, serve("b").Code)
+}
+
+func TestRedisRateLimitMiddlewareFail(t *testing.T) {
+	s, cli := newRedisClient(t)
+	s.Close()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rl := limiter.NewRedisLimiter(cli)
+
+	for _, tc := range []struct {
+		failOpen bool
+		code     int
+	}{
+		{true, http.StatusOK},
+		{false, http.StatusServiceUnavailable},
+	} {
+		handler := limiter.RedisRateLimitMiddleware(next, rl, 2, time.Minute, &limiter.MiddlewareConfig{
+			FailOpen: tc.failOpen,
+		})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api", nil))
+		require.Equal(t, tc.code, w.Code)
+	}
+}
@@ -26,6 +26,10 @@ func UberRateWaitMiddleware(next http.Handler, limitPerSecond int) http.Handler
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RedisRateWaitMiddleware 进程内的等待式限流，并未使用redis
+//
+// Deprecated: 分布式限流请使用 RedisRateLimitMiddleware
 func RedisRateWaitMiddleware(next http.Handler, limitPerSecond int) http.Handler {
 	// 初始化：每秒10个令牌，桶容量30
 	rl := ratelimit.New(limitPerSecond)
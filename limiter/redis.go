@@ -11,12 +11,22 @@ type RedisLimiter struct {
 	script *redis.Script
 }
 
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 窗口内的总配额
+	Remaining  int           // 剩余配额
+	ResetAfter time.Duration // 多久后配额完全恢复
+	RetryAfter time.Duration // 被拒绝时，建议多久后重试
+}
+
 var rateLimiterLua = `
 -- ratelimiter.lua
 local key = KEYS[1]          -- 限流key
-local now = tonumber(ARGV[1]) -- 当前时间戳
-local window = tonumber(ARGV[2]) * 1000 -- 窗口时间(转毫秒)
-local limit = tonumber(ARGV[3])         -- 阈值
+local now = tonumber(ARGV[1]) -- 当前时间戳(毫秒)
+local window = tonumber(ARGV[2]) -- 窗口时间(毫秒)
+local limit = tonumber(ARGV[3])  -- 阈值
+local cost = tonumber(ARGV[4] or '1') -- 本次消耗的次数
 
 -- 移除窗口外的数据
 redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
@@ -24,12 +34,28 @@ redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
 -- 获取当前请求数
 local current = redis.call('ZCARD', key)
 
-if current < limit then
-    redis.call('ZADD', key, now, now .. '-' .. math.random())
+local allowed = 0
+if current + cost <= limit then
+    for i = 1, cost do
+        redis.call('ZADD', key, now, now .. '-' .. i .. '-' .. math.random())
+    end
     redis.call('PEXPIRE', key, window)
-    return 1 -- 允许访问
+    current = current + cost
+    allowed = 1 -- 允许访问
 end
-return 0 -- 拒绝访问
+
+-- 最早的一条记录移出窗口的时间，即为下次可访问的时间
+local resetAfter = 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+    resetAfter = tonumber(oldest[2]) + window - now
+end
+
+local retryAfter = 0
+if allowed == 0 and cost <= limit then
+    retryAfter = resetAfter
+end
+return {allowed, limit - current, resetAfter, retryAfter}
 `
 
 func NewRedisLimiter(client *redis.Client) *RedisLimiter {
@@ -39,16 +65,47 @@ func NewRedisLimiter(client *redis.Client) *RedisLimiter {
 	}
 }
 
+// Allow 滑动窗口判断是否放行
 func (rl *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	ret, err := rl.Take(ctx, key, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return ret.Allowed, nil
+}
+
+// Take 滑动窗口判断是否放行，并返回剩余配额等信息
+func (rl *RedisLimiter) Take(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	return rl.takeN(ctx, key, limit, window, 1)
+}
+
+func (rl *RedisLimiter) takeN(ctx context.Context, key string, limit int, window time.Duration, n int) (*Result, error) {
 	now := time.Now().UnixMilli()
-	result, err := rl.script.Run(ctx, rl.client, []string{key},
+	values, err := rl.script.Run(ctx, rl.client, []string{key},
 		now,
 		window.Milliseconds(),
 		limit,
-	).Int()
+		n,
+	).Int64Slice()
 
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	return newResult(limit, values), nil
+}
+
+// newResult 将lua脚本返回的 {allowed, remaining, resetAfter, retryAfter} 转为结果
+func newResult(limit int, values []int64) *Result {
+	ret := &Result{Limit: limit}
+	if len(values) < 4 {
+		return ret
+	}
+	ret.Allowed = values[0] == 1
+	ret.Remaining = int(values[1])
+	if ret.Remaining < 0 {
+		ret.Remaining = 0
 	}
-	return result == 1, nil
+	ret.ResetAfter = time.Duration(values[2]) * time.Millisecond
+	ret.RetryAfter = time.Duration(values[3]) * time.Millisecond
+	return ret
 }
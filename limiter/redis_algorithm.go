@@ -0,0 +1,300 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// 以下脚本的参数统一为：ARGV = {now(毫秒), period(毫秒), limit, burst, cost, reserve, windowStart(毫秒)}
+// 返回值统一为：{allowed, remaining, resetAfter(毫秒), retryAfter(毫秒)}
+
+// gcraLua 通用信元速率算法，只需要存储一个理论到达时间(TAT)，内存占用最小
+var gcraLua = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local cost = tonumber(ARGV[5])
+local reserve = tonumber(ARGV[6])
+
+local emission = period / limit      -- 每个请求的间隔
+local increment = emission * cost
+local burstOffset = emission * burst
+
+local tat = now
+local stored = redis.call('GET', key)
+if stored then
+    tat = math.max(tonumber(stored), now)
+end
+
+local newTat = tat + increment
+local diff = now - (newTat - burstOffset)
+if diff < 0 and (reserve == 0 or increment > burstOffset) then
+    local retryAfter = math.ceil(-diff)
+    if increment > burstOffset then
+        retryAfter = 0 -- 永远不可能满足
+    end
+    return {0, 0, math.ceil(tat - now), retryAfter}
+end
+
+local resetAfter = math.ceil(newTat - now)
+if resetAfter > 0 then
+    redis.call('SET', key, tostring(newTat), 'PX', resetAfter)
+end
+
+local remaining = 0
+local retryAfter = 0
+if diff >= 0 then
+    remaining = math.floor(diff / emission)
+else
+    retryAfter = math.ceil(-diff)
+end
+return {1, remaining, resetAfter, retryAfter}
+`
+
+// tokenBucketLua 令牌桶，存储剩余令牌数和最后更新时间，预订时令牌数可以为负
+var tokenBucketLua = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local cost = tonumber(ARGV[5])
+local reserve = tonumber(ARGV[6])
+
+local rate = limit / period -- 每毫秒生成的令牌数
+local data = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = burst
+local ts = now
+if data[1] then
+    tokens = tonumber(data[1])
+end
+if data[2] then
+    ts = tonumber(data[2])
+end
+if now > ts then
+    tokens = math.min(burst, tokens + (now - ts) * rate)
+    ts = now
+end
+
+local allowed = 0
+local retryAfter = 0
+if tokens >= cost then
+    tokens = tokens - cost
+    allowed = 1
+elseif cost <= burst then
+    retryAfter = math.ceil((cost - tokens) / rate)
+    if reserve == 1 then
+        tokens = tokens - cost
+        allowed = 1
+    end
+end
+
+local resetAfter = math.ceil((burst - tokens) / rate)
+redis.call('HMSET', key, 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', key, resetAfter + 1000)
+
+local remaining = 0
+if tokens > 0 then
+    remaining = math.floor(tokens)
+end
+return {allowed, remaining, resetAfter, retryAfter}
+`
+
+// fixedWindowLua 固定窗口计数，KEYS[1] 为当前窗口的key
+var fixedWindowLua = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+local windowStart = tonumber(ARGV[7])
+
+local resetAfter = windowStart + period - now
+local current = tonumber(redis.call('GET', key) or '0')
+if current + cost <= limit then
+    current = redis.call('INCRBY', key, cost)
+    redis.call('PEXPIRE', key, resetAfter + 1000)
+    return {1, limit - current, resetAfter, 0}
+end
+
+local retryAfter = resetAfter
+if cost > limit then
+    retryAfter = 0
+end
+return {0, math.max(limit - current, 0), resetAfter, retryAfter}
+`
+
+// slidingWindowLua 滑动窗口计数，用上一个窗口按时间加权估算，KEYS = {当前窗口, 上一个窗口}
+var slidingWindowLua = `
+local key = KEYS[1]
+local prevKey = KEYS[2]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+local windowStart = tonumber(ARGV[7])
+
+local elapsed = now - windowStart
+local prev = tonumber(redis.call('GET', prevKey) or '0')
+local current = tonumber(redis.call('GET', key) or '0')
+local weight = (period - elapsed) / period
+local count = prev * weight + current
+
+local resetAfter = period - elapsed
+if current > 0 then
+    resetAfter = resetAfter + period
+end
+
+if count + cost <= limit then
+    current = redis.call('INCRBY', key, cost)
+    redis.call('PEXPIRE', key, period * 2)
+    count = prev * weight + current
+    return {1, math.floor(limit - count), period - elapsed + period, 0}
+end
+
+local retryAfter = period - elapsed
+if cost > limit then
+    retryAfter = 0
+elseif prev > 0 then
+    -- 上一个窗口的权重逐渐降低，计算降到可以放行的时间
+    local wait = (count + cost - limit) * period / prev
+    if wait < retryAfter then
+        retryAfter = wait
+    end
+end
+return {0, math.max(math.floor(limit - count), 0), math.ceil(resetAfter), math.ceil(retryAfter)}
+`
+
+type algorithmKind int
+
+const (
+	algorithmGCRA algorithmKind = iota
+	algorithmTokenBucket
+	algorithmFixedWindow
+	algorithmSlidingWindow
+)
+
+// redisAlgorithm redis限流算法的统一实现
+type redisAlgorithm struct {
+	client *redis.Client
+	script *redis.Script
+	kind   algorithmKind
+	rate   Rate
+}
+
+// NewGCRALimiter 新建GCRA限流器，每个key只存储一个时间戳，适合高QPS场景
+func NewGCRALimiter(client *redis.Client, r Rate) Limiter {
+	return newRedisAlgorithm(client, algorithmGCRA, gcraLua, r)
+}
+
+// NewTokenBucketLimiter 新建令牌桶限流器，Burst 为桶的容量
+func NewTokenBucketLimiter(client *redis.Client, r Rate) Limiter {
+	return newRedisAlgorithm(client, algorithmTokenBucket, tokenBucketLua, r)
+}
+
+// NewFixedWindowLimiter 新建固定窗口计数限流器，窗口边界处可能放行2倍的请求
+func NewFixedWindowLimiter(client *redis.Client, r Rate) Limiter {
+	return newRedisAlgorithm(client, algorithmFixedWindow, fixedWindowLua, r)
+}
+
+// NewSlidingWindowLimiter 新建滑动窗口计数限流器，用相邻两个窗口的计数近似滑动窗口
+func NewSlidingWindowLimiter(client *redis.Client, r Rate) Limiter {
+	return newRedisAlgorithm(client, algorithmSlidingWindow, slidingWindowLua, r)
+}
+
+func newRedisAlgorithm(client *redis.Client, kind algorithmKind, lua string, r Rate) *redisAlgorithm {
+	return &redisAlgorithm{
+		client: client,
+		script: redis.NewScript(lua),
+		kind:   kind,
+		rate:   r.normalize(),
+	}
+}
+
+// AllowN 是否可以立即通过
+func (l *redisAlgorithm) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.run(ctx, key, n, false)
+}
+
+// ReserveN 预订配额，只有GCRA和令牌桶支持预订未来的配额
+func (l *redisAlgorithm) ReserveN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.run(ctx, key, n, true)
+}
+
+// WaitN 等待直到可以通过
+func (l *redisAlgorithm) WaitN(ctx context.Context, key string, n int) error {
+	return waitN(ctx, l, key, n)
+}
+
+func (l *redisAlgorithm) run(ctx context.Context, key string, n int, reserve bool) (*Result, error) {
+	now := time.Now().UnixMilli()
+	period := l.rate.Period.Milliseconds()
+	windowStart := now - now%period
+
+	keys := []string{key}
+	limit := l.rate.Burst
+	switch l.kind {
+	case algorithmFixedWindow:
+		limit = l.rate.Limit
+		keys = []string{windowKey(key, windowStart)}
+	case algorithmSlidingWindow:
+		limit = l.rate.Limit
+		keys = []string{windowKey(key, windowStart), windowKey(key, windowStart-period)}
+	}
+
+	reserveFlag := 0
+	if reserve {
+		reserveFlag = 1
+	}
+	values, err := l.script.Run(ctx, l.client, keys,
+		now,
+		period,
+		l.rate.Limit,
+		l.rate.Burst,
+		n,
+		reserveFlag,
+		windowStart,
+	).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return newResult(limit, values), nil
+}
+
+// windowKey 窗口计数的key，用 hash tag 保证集群模式下同一个key的窗口在同一个slot
+func windowKey(key string, windowStart int64) string {
+	return fmt.Sprintf("{%s}:%d", key, windowStart)
+}
+
+// slidingLogLimiter 将 RedisLimiter 的滑动日志算法适配为 Limiter
+type slidingLogLimiter struct {
+	rl   *RedisLimiter
+	rate Rate
+}
+
+// NewSlidingLogLimiter 新建滑动日志限流器，精确但每个请求占用一条ZSET记录
+func NewSlidingLogLimiter(client *redis.Client, r Rate) Limiter {
+	return &slidingLogLimiter{
+		rl:   NewRedisLimiter(client),
+		rate: r.normalize(),
+	}
+}
+
+// AllowN 是否可以立即通过
+func (l *slidingLogLimiter) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.rl.takeN(ctx, key, l.rate.Limit, l.rate.Period, n)
+}
+
+// ReserveN 滑动日志不支持预订，与 AllowN 相同
+func (l *slidingLogLimiter) ReserveN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.AllowN(ctx, key, n)
+}
+
+// WaitN 等待直到可以通过
+func (l *slidingLogLimiter) WaitN(ctx context.Context, key string, n int) error {
+	return waitN(ctx, l, key, n)
+}
@@ -6,23 +6,40 @@ type ILogger interface {
 	Info(v ...interface{})
 	Warn(v ...interface{})
 	Error(v ...interface{})
+	Notice(v ...interface{})
+	Critical(v ...interface{})
+	Alert(v ...interface{})
+	Emergency(v ...interface{})
+
+	// With 返回带有固定字段的子日志，参数为 Field 或 key, value 交替
+	With(kv ...interface{}) ILogger
+	Debugw(msg string, kv ...interface{})
+	Infow(msg string, kv ...interface{})
+	Warnw(msg string, kv ...interface{})
+	Errorw(msg string, kv ...interface{})
 
 	Level() LogLevel
 	SetLevel(l LogLevel)
 }
 
-// Logger 直接根据等级打印所有日志
+// Logger 直接根据等级打印所有日志，未定义的等级向下取最近的
 func Logger(logger ILogger, l LogLevel, msg ...interface{}) {
 	if l <= DEBUG {
 		logger.Debug(msg...)
-	} else if l <= INFO {
+	} else if l < NOTICE {
 		logger.Info(msg...)
-	} else if l <= WARNING {
+	} else if l < WARNING {
+		logger.Notice(msg...)
+	} else if l < ERROR {
 		logger.Warn(msg...)
-	} else if l <= ERROR {
+	} else if l < CRITICAL {
 		logger.Error(msg...)
+	} else if l < ALERT {
+		logger.Critical(msg...)
+	} else if l < EMERGENCY {
+		logger.Alert(msg...)
 	} else {
-		logger.Debug(msg...)
+		logger.Emergency(msg...)
 	}
 }
 
@@ -0,0 +1,326 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"github.com/tianlin0/go-plat-utils/cleaner"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy 缓冲区满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest 丢弃最早的日志
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropDebug 优先丢弃最早的 DEBUG 日志，没有时再丢弃最早的日志
+	OverflowDropDebug
+	// OverflowBlock 阻塞写日志的协程，直到有空位
+	OverflowBlock
+)
+
+// AsyncConfig 异步日志的配置
+type AsyncConfig struct {
+	Name           string
+	BufferSize     int            //缓冲的日志条数，默认4096
+	BatchSize      int            //每批写入sink的条数，默认100
+	FlushInterval  time.Duration  //不满一批时最长等待时间，默认1秒
+	Overflow       OverflowPolicy //缓冲区满时的策略
+	DrainTimeout   time.Duration  //停止时等待写完的最长时间，默认5秒，超时后未写出的日志会丢弃
+	Sinks          []Sink
+	OnError        func(sink string, err error) //sink写入失败的回调，默认输出到stderr
+	DisableCleaner bool                         //不注册到 cleaner，需要自己调用 Stop
+}
+
+// AsyncStats 异步日志的统计
+type AsyncStats struct {
+	Received uint64 //收到的日志条数
+	Written  uint64 //写入sink的日志条数
+	Dropped  uint64 //缓冲区满或停止后丢弃的条数
+	Failed   uint64 //sink写入失败的批次
+	Buffered int    //当前缓冲的条数
+}
+
+// AsyncDispatcher 异步日志分发，日志先进入有界的环形缓冲区，由后台协程批量写入所有 sink
+type AsyncDispatcher struct {
+	cfg AsyncConfig
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	buf     *ringBuffer
+	stopped bool
+	expired atomic.Bool //停止时超过 DrainTimeout，不再写出缓冲区剩余的日志
+
+	notify  chan struct{}
+	flushCh chan chan struct{}
+	stopCh  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	received atomic.Uint64
+	written  atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+// NewAsyncDispatcher 新建异步日志分发，Execute 可以作为 LogExecute 传给 NewCtxLogger 或 WithLogExecute
+func NewAsyncDispatcher(cfg AsyncConfig) *AsyncDispatcher {
+	if cfg.Name == "" {
+		cfg.Name = "async-logger"
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 4096
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 5 * time.Second
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(sink string, err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "async logger sink %s error: %v\n", sink, err)
+		}
+	}
+
+	d := &AsyncDispatcher{
+		cfg:     cfg,
+		buf:     newRingBuffer(cfg.BufferSize),
+		notify:  make(chan struct{}, 1),
+		flushCh: make(chan chan struct{}),
+		stopCh:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	d.notFull = sync.NewCond(&d.mu)
+
+	go d.run()
+
+	if !cfg.DisableCleaner {
+		cleaner.Register(d)
+	}
+	return d
+}
+
+// Name 名称，实现 cleaner.Cleanable
+func (d *AsyncDispatcher) Name() string {
+	return d.cfg.Name
+}
+
+// Execute 将日志放入缓冲区，实现 LogExecute
+func (d *AsyncDispatcher) Execute(_ context.Context, logInfo *LogData) {
+	if logInfo == nil || len(logInfo.Message) == 0 {
+		return
+	}
+	d.received.Add(1)
+
+	d.mu.Lock()
+	for d.buf.full() && !d.stopped {
+		if d.cfg.Overflow == OverflowBlock {
+			d.notFull.Wait()
+			continue
+		}
+		if d.cfg.Overflow == OverflowDropDebug {
+			if d.buf.removeFirst(func(one *LogData) bool { return one.LogLevel <= DEBUG }) {
+				d.dropped.Add(1)
+				break
+			}
+			if logInfo.LogLevel <= DEBUG {
+				d.mu.Unlock()
+				d.dropped.Add(1)
+				return
+			}
+		}
+		d.buf.pop(1)
+		d.dropped.Add(1)
+	}
+	if d.stopped {
+		d.mu.Unlock()
+		d.dropped.Add(1)
+		return
+	}
+	d.buf.push(logInfo)
+	reachBatch := d.buf.len() >= d.cfg.BatchSize
+	d.mu.Unlock()
+
+	if reachBatch {
+		select {
+		case d.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush 等待缓冲区中已有的日志写入所有 sink
+func (d *AsyncDispatcher) Flush() {
+	ack := make(chan struct{})
+	select {
+	case d.flushCh <- ack:
+		<-ack
+	case <-d.done:
+	}
+}
+
+// Stop 停止接收日志，等待缓冲区写完后关闭所有 sink，实现 cleaner.Cleanable。
+// 最多等待 DrainTimeout，超时后缓冲区中还没写出的日志会被丢弃，正在写入的一批写完后才关闭 sink
+func (d *AsyncDispatcher) Stop() {
+	d.once.Do(func() {
+		d.mu.Lock()
+		d.stopped = true
+		d.notFull.Broadcast()
+		d.mu.Unlock()
+
+		close(d.stopCh)
+		select {
+		case <-d.done:
+		case <-time.After(d.cfg.DrainTimeout):
+			d.expired.Store(true)
+			<-d.done
+		}
+
+		for _, sink := range d.cfg.Sinks {
+			if err := sink.Close(); err != nil {
+				d.cfg.OnError(sink.Name(), err)
+			}
+		}
+	})
+}
+
+// Stats 统计信息
+func (d *AsyncDispatcher) Stats() AsyncStats {
+	d.mu.Lock()
+	buffered := d.buf.len()
+	d.mu.Unlock()
+	return AsyncStats{
+		Received: d.received.Load(),
+		Written:  d.written.Load(),
+		Dropped:  d.dropped.Load(),
+		Failed:   d.failed.Load(),
+		Buffered: buffered,
+	}
+}
+
+func (d *AsyncDispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.notify:
+			d.drain()
+		case <-ticker.C:
+			d.drain()
+		case ack := <-d.flushCh:
+			d.drain()
+			close(ack)
+		case <-d.stopCh:
+			d.drain()
+			return
+		}
+	}
+}
+
+// drain 按批次写出缓冲区中所有的日志
+func (d *AsyncDispatcher) drain() {
+	for {
+		d.mu.Lock()
+		batch := d.buf.pop(d.cfg.BatchSize)
+		d.notFull.Broadcast()
+		d.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+		if d.expired.Load() {
+			d.dropped.Add(uint64(len(batch)))
+			continue
+		}
+		d.write(batch)
+	}
+}
+
+func (d *AsyncDispatcher) write(batch []*LogData) {
+	for _, sink := range d.cfg.Sinks {
+		if err := writeSink(sink, batch); err != nil {
+			d.failed.Add(1)
+			d.cfg.OnError(sink.Name(), err)
+		}
+	}
+	d.written.Add(uint64(len(batch)))
+}
+
+// writeSink 单个sink的panic不影响其他sink
+func writeSink(sink Sink, batch []*LogData) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sink.Write(batch)
+}
+
+// ringBuffer 固定容量的环形缓冲区，非并发安全
+type ringBuffer struct {
+	items []*LogData
+	head  int
+	size  int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{items: make([]*LogData, capacity)}
+}
+
+func (r *ringBuffer) len() int {
+	return r.size
+}
+
+func (r *ringBuffer) full() bool {
+	return r.size == len(r.items)
+}
+
+func (r *ringBuffer) push(one *LogData) {
+	r.items[(r.head+r.size)%len(r.items)] = one
+	r.size++
+}
+
+// pop 从头部取出最多n条
+func (r *ringBuffer) pop(n int) []*LogData {
+	if n > r.size {
+		n = r.size
+	}
+	if n == 0 {
+		return nil
+	}
+	ret := make([]*LogData, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, r.items[r.head])
+		r.items[r.head] = nil
+		r.head = (r.head + 1) % len(r.items)
+	}
+	r.size -= n
+	return ret
+}
+
+// removeFirst 删除第一条满足条件的日志，后面的依次前移
+func (r *ringBuffer) removeFirst(match func(one *LogData) bool) bool {
+	capacity := len(r.items)
+	for i := 0; i < r.size; i++ {
+		if !match(r.items[(r.head+i)%capacity]) {
+			continue
+		}
+		for j := i; j < r.size-1; j++ {
+			r.items[(r.head+j)%capacity] = r.items[(r.head+j+1)%capacity]
+		}
+		r.items[(r.head+r.size-1)%capacity] = nil
+		r.size--
+		return true
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+type memSink struct {
+	mu      sync.Mutex
+	block   chan struct{}
+	batches [][]string
+	closed  bool
+}
+
+func (s *memSink) Name() string { return "mem" }
+
+func (s *memSink) Write(batch []*logs.LogData) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("write after close")
+	}
+	msgs := make([]string, 0, len(batch))
+	for _, one := range batch {
+		msgs = append(msgs, fmt.Sprint(one.Message...))
+	}
+	s.batches = append(s.batches, msgs)
+	return nil
+}
+
+func (s *memSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]string, 0)
+	for _, one := range s.batches {
+		ret = append(ret, one...)
+	}
+	return ret
+}
+
+func newLogData(level logs.LogLevel, msg string) *logs.LogData {
+	logData := logs.NewLogData(&logs.LogCommData{LogId: "log-1"})
+	logData.AddMessage(level, "", 0, msg)
+	return logData
+}
+
+func TestAsyncDispatcherBatch(t *testing.T) {
+	sink := new(memSink)
+	d := logs.NewAsyncDispatcher(logs.AsyncConfig{
+		BatchSize:      2,
+		FlushInterval:  time.Hour,
+		Sinks:          []logs.Sink{sink},
+		DisableCleaner: true,
+	})
+
+	logger := logs.NewPrintLogger(logs.DEBUG).WithLogExecute(d.Execute)
+	for i := 1; i <= 5; i++ {
+		logger.Info(fmt.Sprintf("m%d", i))
+	}
+	d.Flush()
+	require.Equal(t, []string{"m1", "m2", "m3", "m4", "m5"}, sink.messages())
+	for _, batch := range sink.batches {
+		require.LessOrEqual(t, len(batch), 2)
+	}
+
+	d.Stop()
+	require.True(t, sink.closed)
+	d.Execute(context.Background(), newLogData(logs.INFO, "after stop"))
+	stats := d.Stats()
+	require.Equal(t, uint64(6), stats.Received)
+	require.Equal(t, uint64(5), stats.Written)
+	require.Equal(t, uint64(1), stats.Dropped)
+}
+
+func TestAsyncDispatcherOverflow(t *testing.T) {
+	newDispatcher := func(policy logs.OverflowPolicy) (*logs.AsyncDispatcher, *memSink) {
+		sink := new(memSink)
+		return logs.NewAsyncDispatcher(logs.AsyncConfig{
+			BufferSize:     3,
+			BatchSize:      10,
+			FlushInterval:  time.Hour,
+			Overflow:       policy,
+			Sinks:          []logs.Sink{sink},
+			DisableCleaner: true,
+		}), sink
+	}
+
+	d, sink := newDispatcher(logs.OverflowDropOldest)
+	for i := 1; i <= 5; i++ {
+		d.Execute(context.Background(), newLogData(logs.INFO, fmt.Sprintf("m%d", i)))
+	}
+	require.Equal(t, 3, d.Stats().Buffered)
+	d.Stop()
+	require.Equal(t, []string{"m3", "m4", "m5"}, sink.messages())
+	require.Equal(t, uint64(2), d.Stats().Dropped)
+
+	d, sink = newDispatcher(logs.OverflowDropDebug)
+	d.Execute(context.Background(), newLogData(logs.DEBUG, "d1"))
+	d.Execute(context.Background(), newLogData(logs.INFO, "i2"))
+	d.Execute(context.Background(), newLogData(logs.DEBUG, "d3"))
+	d.Execute(context.Background(), newLogData(logs.ERROR, "e4"))
+	d.Execute(context.Background(), newLogData(logs.WARNING, "w5"))
+	d.Execute(context.Background(), newLogData(logs.DEBUG, "d6"))
+	d.Execute(context.Background(), newLogData(logs.INFO, "i7"))
+	d.Stop()
+	require.Equal(t, []string{"e4", "w5", "i7"}, sink.messages())
+	require.Equal(t, uint64(4), d.Stats().Dropped)
+}
+
+func TestAsyncDispatcherBlock(t *testing.T) {
+	sink := &memSink{block: make(chan struct{})}
+	d := logs.NewAsyncDispatcher(logs.AsyncConfig{
+		BufferSize:     1,
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		Overflow:       logs.OverflowBlock,
+		Sinks:          []logs.Sink{sink},
+		DisableCleaner: true,
+	})
+
+	d.Execute(context.Background(), newLogData(logs.INFO, "m1"))
+	require.Eventually(t, func() bool { return d.Stats().Buffered == 0 }, time.Second, time.Millisecond)
+	d.Execute(context.Background(), newLogData(logs.INFO, "m2"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Execute(context.Background(), newLogData(logs.INFO, "m3"))
+	}()
+	select {
+	case <-done:
+		t.Fatal("execute should block when buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.block)
+	<-done
+	d.Stop()
+	require.Equal(t, []string{"m1", "m2", "m3"}, sink.messages())
+	require.Equal(t, uint64(0), d.Stats().Dropped)
+}
+
+func TestAsyncDispatcherDrainTimeout(t *testing.T) {
+	sink := &memSink{block: make(chan struct{})}
+	writeErr := make(chan error, 1)
+	d := logs.NewAsyncDispatcher(logs.AsyncConfig{
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		DrainTimeout:  20 * time.Millisecond,
+		Sinks:         []logs.Sink{sink},
+		OnError: func(sink string, err error) {
+			writeErr <- err
+		},
+		DisableCleaner: true,
+	})
+	for i := 1; i <= 3; i++ {
+		d.Execute(context.Background(), newLogData(logs.INFO, fmt.Sprintf("m%d", i)))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		d.Stop()
+	}()
+	// 超时后要等正在写入的一批写完才关闭 sink
+	select {
+	case <-stopped:
+		t.Fatal("stop should wait for the batch in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	sink.mu.Lock()
+	require.False(t, sink.closed)
+	sink.mu.Unlock()
+
+	close(sink.block)
+	<-stopped
+	require.True(t, sink.closed)
+	require.Equal(t, []string{"m1"}, sink.messages())
+	require.Empty(t, writeErr)
+	require.Equal(t, uint64(2), d.Stats().Dropped)
+}
+
+type memMessageWriter struct {
+	msgs   []logs.Message
+	closed bool
+}
+
+func (w *memMessageWriter) WriteMessages(ctx context.Context, msgs ...logs.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *memMessageWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestAsyncSinks(t *testing.T) {
+	bodies := make(chan []map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		list := make([]map[string]interface{}, 0)
+		_ = json.Unmarshal(body, &list)
+		bodies <- list
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	writer := new(memMessageWriter)
+	d := logs.NewAsyncDispatcher(logs.AsyncConfig{
+		FlushInterval: time.Hour,
+		Sinks: []logs.Sink{
+			logs.NewWriterSink("buffer", buf, nil),
+			logs.NewHTTPSink(logs.HTTPSinkConfig{URL: server.URL}),
+			logs.NewMessageSink("queue", writer, nil),
+		},
+		DisableCleaner: true,
+	})
+	d.Execute(context.Background(), newLogData(logs.WARNING, "hello"))
+	d.Stop()
+
+	require.Contains(t, buf.String(), "WARNING log-1")
+	require.Contains(t, buf.String(), "hello")
+	list := <-bodies
+	require.Len(t, list, 1)
+	require.Equal(t, "hello", list[0]["message"])
+	require.Len(t, writer.msgs, 1)
+	require.Equal(t, "log-1", string(writer.msgs[0].Key))
+	require.True(t, writer.closed)
+}
@@ -11,6 +11,9 @@ type ctxLogger struct {
 	logCommData *LogCommData
 	logLevel    LogLevel
 	callerSkip  int
+	fields      Fields    //With 添加的固定字段
+	redactor    *Redactor //脱敏，为空时不处理
+	sampler     *Sampler  //采样，为空时不处理
 }
 
 // NewCtxLogger 例子，一个完整的日志，需要实现如下方法
@@ -24,6 +27,7 @@ func NewCtxLogger(ctx context.Context, level LogLevel, logFunc LogExecute, logCo
 	if logCommData != nil && len(logCommData) > 0 {
 		paramLogger.logCommData = logCommData[0]
 	}
+	paramLogger.logCommData = commDataFromBaggage(ctx, paramLogger.logCommData)
 
 	cLogger.buildLogger(ctx, paramLogger)
 	newCtx := setLoggerToContext(ctx, cLogger)
@@ -37,6 +41,18 @@ func (x *ctxLogger) SetCallerSkip(skip int) *ctxLogger {
 	return x
 }
 
+// WithRedactor 输出前对日志脱敏
+func (x *ctxLogger) WithRedactor(r *Redactor) *ctxLogger {
+	x.redactor = r
+	return x
+}
+
+// WithSampler 按调用位置采样
+func (x *ctxLogger) WithSampler(s *Sampler) *ctxLogger {
+	x.sampler = s
+	return x
+}
+
 // WithLogExecute 自定义方法
 func (x *ctxLogger) WithLogExecute(logExec LogExecute) *ctxLogger {
 	if logExec != nil {
@@ -50,7 +66,7 @@ func (x *ctxLogger) Debug(v ...interface{}) {
 	if x.Level() > DEBUG {
 		return
 	}
-	x.printlnComm(DEBUG, v...)
+	x.printlnComm(DEBUG, nil, v...)
 }
 
 // Error Error
@@ -58,7 +74,7 @@ func (x *ctxLogger) Error(v ...interface{}) {
 	if x.Level() > ERROR {
 		return
 	}
-	x.printlnComm(ERROR, v...)
+	x.printlnComm(ERROR, nil, v...)
 }
 
 // Info Info
@@ -66,7 +82,7 @@ func (x *ctxLogger) Info(v ...interface{}) {
 	if x.Level() > INFO {
 		return
 	}
-	x.printlnComm(INFO, v...)
+	x.printlnComm(INFO, nil, v...)
 }
 
 // Warn xx
@@ -74,7 +90,78 @@ func (x *ctxLogger) Warn(v ...interface{}) {
 	if x.Level() > WARNING {
 		return
 	}
-	x.printlnComm(WARNING, v...)
+	x.printlnComm(WARNING, nil, v...)
+}
+
+// Notice Notice
+func (x *ctxLogger) Notice(v ...interface{}) {
+	if x.Level() > NOTICE {
+		return
+	}
+	x.printlnComm(NOTICE, nil, v...)
+}
+
+// Critical Critical
+func (x *ctxLogger) Critical(v ...interface{}) {
+	if x.Level() > CRITICAL {
+		return
+	}
+	x.printlnComm(CRITICAL, nil, v...)
+}
+
+// Alert Alert
+func (x *ctxLogger) Alert(v ...interface{}) {
+	if x.Level() > ALERT {
+		return
+	}
+	x.printlnComm(ALERT, nil, v...)
+}
+
+// Emergency Emergency
+func (x *ctxLogger) Emergency(v ...interface{}) {
+	if x.Level() > EMERGENCY {
+		return
+	}
+	x.printlnComm(EMERGENCY, nil, v...)
+}
+
+// With 返回带有固定字段的子日志
+func (x *ctxLogger) With(kv ...interface{}) ILogger {
+	child := *x
+	child.fields = mergeFields(x.fields, toFields(kv...))
+	return &child
+}
+
+// Debugw 带结构化字段的 Debug
+func (x *ctxLogger) Debugw(msg string, kv ...interface{}) {
+	if x.Level() > DEBUG {
+		return
+	}
+	x.printlnComm(DEBUG, toFields(kv...), msg)
+}
+
+// Errorw 带结构化字段的 Error
+func (x *ctxLogger) Errorw(msg string, kv ...interface{}) {
+	if x.Level() > ERROR {
+		return
+	}
+	x.printlnComm(ERROR, toFields(kv...), msg)
+}
+
+// Infow 带结构化字段的 Info
+func (x *ctxLogger) Infow(msg string, kv ...interface{}) {
+	if x.Level() > INFO {
+		return
+	}
+	x.printlnComm(INFO, toFields(kv...), msg)
+}
+
+// Warnw 带结构化字段的 Warn
+func (x *ctxLogger) Warnw(msg string, kv ...interface{}) {
+	if x.Level() > WARNING {
+		return
+	}
+	x.printlnComm(WARNING, toFields(kv...), msg)
 }
 
 // Level xx
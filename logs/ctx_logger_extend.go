@@ -6,7 +6,7 @@ import (
 	"github.com/tianlin0/go-plat-utils/utils"
 )
 
-func (x *ctxLogger) printlnComm(level LogLevel, msg ...interface{}) {
+func (x *ctxLogger) printlnComm(level LogLevel, fields Fields, msg ...interface{}) {
 	if len(msg) == 0 {
 		return
 	}
@@ -21,16 +21,58 @@ func (x *ctxLogger) printlnComm(level LogLevel, msg ...interface{}) {
 		line = file.Line
 	}
 	logNewInfo.AddMessage(level, fileName, line, msg...)
+	logNewInfo.Fields = mergeFields(x.fields, fields)
+	if !x.sampler.allow(logNewInfo, x.logExecute) {
+		return
+	}
+	logNewInfo = x.redactor.Redact(logNewInfo)
 
-	ctxPtr, _, _ := goroutines.GetContext()
-	if ctxPtr == nil {
-		ctxPtr := x.ctx
-		if ctxPtr == nil {
-			ctx := context.Background()
-			ctxPtr = &ctx
-		}
+	ctx := x.context()
+	x.logExecute(ctx, logNewInfo)
+	RunEmergencyHooks(ctx, logNewInfo)
+}
+
+// context 日志的上下文，优先使用创建日志时传入的ctx
+func (x *ctxLogger) context() context.Context {
+	if x.ctx != nil && *x.ctx != nil {
+		return *x.ctx
+	}
+	return goroutineContext()
+}
+
+// goroutineContext 兼容旧的按协程保存的上下文，设置 DisableGoroutineContext 后不再查找
+func goroutineContext() context.Context {
+	if GetConfig().DisableGoroutineContext {
+		return context.Background()
+	}
+	if ctxPtr, _, _ := goroutines.GetContext(); ctxPtr != nil && *ctxPtr != nil {
+		return *ctxPtr
 	}
-	x.logExecute(*ctxPtr, logNewInfo)
+	return context.Background()
+}
+
+// commDataFromBaggage 用ctx中的 baggage 补充日志的公共数据，返回新的副本，不修改传入的
+func commDataFromBaggage(ctx context.Context, commData *LogCommData) *LogCommData {
+	baggage, ok := goroutines.BaggageFromContext(ctx)
+	if !ok {
+		return commData
+	}
+	if commData == nil {
+		commData = new(LogCommData)
+	} else {
+		copied := *commData
+		commData = &copied
+	}
+	if commData.LogId == "" {
+		commData.LogId = baggage.LogId
+	}
+	if commData.UserId == "" {
+		commData.UserId = baggage.UserId
+	}
+	if commData.TraceId == "" {
+		commData.TraceId = baggage.TraceId
+	}
+	return commData
 }
 
 // 获取一个logger
@@ -70,7 +112,9 @@ func setLoggerToContext(ctx context.Context, logger *ctxLogger) context.Context
 	}
 	newCtx := context.WithValue(ctx, GetConfig().LoggerCtxName, logger)
 	logger.ctx = &newCtx
-	goroutines.SetContext(&newCtx)
+	if !GetConfig().DisableGoroutineContext {
+		goroutines.SetContext(&newCtx)
+	}
 	return newCtx
 }
 
@@ -89,15 +133,8 @@ func (x *ctxLogger) buildLogger(ctx context.Context, logParam *ctxLogger) {
 		x.logExecute = logParam.logExecute
 	} else {
 		if x.logExecute == nil {
-			x.logExecute = func(ctx context.Context, logInfo *LogData) {
-				if logInfo == nil || len(logInfo.Message) == 0 {
-					return
-				}
-				//默认是控制台打印
-				logger := NewPrintLogger(logInfo.LogLevel, &logInfo.LogCommData)
-				logger.SetCallerSkip(6)
-				Logger(logger, logInfo.LogLevel, logInfo.Message...)
-			}
+			//默认是控制台打印，直接使用已经生成的日志数据，保留文件行号和结构化字段
+			x.logExecute = defaultPrintLogExecute
 		}
 	}
 	if logParam.callerSkip > 0 {
@@ -0,0 +1,71 @@
+package logs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+type ctxKey struct{}
+
+func TestCtxLoggerExplicitContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	ctx = goroutines.WithBaggage(ctx, goroutines.Baggage{LogId: "log-1", UserId: "user-1", TraceId: "trace-1"})
+
+	gotCtx := make(chan context.Context, 1)
+	gotData := make(chan *logs.LogData, 1)
+	logger, ctx := logs.NewCtxLogger(ctx, logs.DEBUG, func(ctx context.Context, logInfo *logs.LogData) {
+		gotCtx <- ctx
+		gotData <- logInfo
+	})
+
+	// 在异步任务中使用显式传递的ctx，不依赖协程上保存的上下文
+	done := make(chan struct{})
+	goroutines.GoCtx(ctx, func(ctx context.Context) {
+		defer close(done)
+		logs.CtxLogger(ctx).Info("async")
+	})
+	<-done
+	require.Same(t, logger, logs.CtxLogger(ctx))
+
+	logCtx := <-gotCtx
+	require.Equal(t, "request", logCtx.Value(ctxKey{}))
+	logData := <-gotData
+	require.Equal(t, "log-1", logData.LogId)
+	require.Equal(t, "user-1", logData.UserId)
+	require.Equal(t, "trace-1", logData.TraceId)
+}
+
+func TestCtxLoggerCommDataTemplate(t *testing.T) {
+	template := &logs.LogCommData{Path: "/user"}
+	gotData := make(chan *logs.LogData, 1)
+	logExecute := func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	}
+
+	for _, logId := range []string{"log-1", "log-2"} {
+		ctx := goroutines.WithBaggage(context.Background(), goroutines.Baggage{LogId: logId})
+		logger, _ := logs.NewCtxLogger(ctx, logs.DEBUG, logExecute, template)
+		logger.Info("hello")
+		logData := <-gotData
+		require.Equal(t, logId, logData.LogId)
+		require.Equal(t, "/user", logData.Path)
+	}
+	// 公共数据作为模板复用时不会被 baggage 修改
+	require.Equal(t, "", template.LogId)
+}
+
+func TestCtxLoggerGoroutineContext(t *testing.T) {
+	_, ctx := logs.NewCtxLogger(context.Background(), logs.DEBUG, nil)
+	defer goroutines.DelContext()
+
+	goroutines.DelContext()
+	logs.CtxLogger(ctx)
+	savedCtx, _, err := goroutines.GetContext()
+	require.Nil(t, err)
+	require.NotNil(t, savedCtx)
+	require.Equal(t, ctx, *savedCtx)
+}
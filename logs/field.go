@@ -0,0 +1,157 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// badKey 键值对不成对或键不是字符串时使用的键名
+const badKey = "!BADKEY"
+
+// Field 结构化日志的一个字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields 有序的字段列表，序列化为json对象时保持添加顺序
+type Fields []Field
+
+// String 字符串字段
+func String(key string, val string) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int 整数字段
+func Int(key string, val int) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int64 int64字段
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Float64 浮点数字段
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Bool 布尔字段
+func Bool(key string, val bool) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Duration 时长字段，输出为可读的字符串，如 1.5s
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Value: val.String()}
+}
+
+// Time 时间字段，输出为 RFC3339Nano 格式
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Value: val.Format(time.RFC3339Nano)}
+}
+
+// Err 错误字段，键名固定为 error
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// Any 任意类型的字段
+func Any(key string, val interface{}) Field {
+	if err, ok := val.(error); ok {
+		return Field{Key: key, Value: err.Error()}
+	}
+	return Field{Key: key, Value: val}
+}
+
+// toFields 将 Field 或 key, value 交替的参数转为字段列表
+func toFields(kv ...interface{}) Fields {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make(Fields, 0, len(kv))
+	for i := 0; i < len(kv); i++ {
+		switch one := kv[i].(type) {
+		case Field:
+			fields = append(fields, one)
+			continue
+		case Fields:
+			fields = append(fields, one...)
+			continue
+		case []Field:
+			fields = append(fields, one...)
+			continue
+		}
+		if i == len(kv)-1 {
+			fields = append(fields, Any(badKey, kv[i]))
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			fields = append(fields, Any(badKey, kv[i]))
+			continue
+		}
+		fields = append(fields, Any(key, kv[i+1]))
+		i++
+	}
+	return fields
+}
+
+// mergeFields 合并字段，返回新的切片，不修改原来的
+func mergeFields(parent Fields, child Fields) Fields {
+	if len(child) == 0 {
+		return parent
+	}
+	fields := make(Fields, 0, len(parent)+len(child))
+	fields = append(fields, parent...)
+	return append(fields, child...)
+}
+
+// Map 转为map，相同的键后面的覆盖前面的
+func (f Fields) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(f))
+	for _, one := range f {
+		m[one.Key] = one.Value
+	}
+	return m
+}
+
+// MarshalJSON 按添加顺序输出json对象，相同的键只保留最后一个
+func (f Fields) MarshalJSON() ([]byte, error) {
+	last := make(map[string]int, len(f))
+	for i, one := range f {
+		last[one.Key] = i
+	}
+	buf := bytes.NewBufferString("{")
+	first := true
+	for i, one := range f {
+		if last[one.Key] != i {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(one.Key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(marshalValue(one.Value))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalValue 单个值转为json，无法转换时（chan、func、NaN等）输出 %+v 的字符串
+func marshalValue(v interface{}) json.RawMessage {
+	val, err := json.Marshal(v)
+	if err != nil {
+		val, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	return val
+}
@@ -0,0 +1,70 @@
+package logs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+func TestStructuredLogger(t *testing.T) {
+	gotData := make(chan *logs.LogData, 2)
+	logger, _ := logs.NewCtxLogger(context.Background(), logs.INFO, func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	})
+
+	child := logger.With("module", "order", logs.Int("shard", 3))
+	child.Infow("created", "amount", 12.5, logs.Bool("paid", true), logs.Err(errors.New("none")), "odd")
+	child.Debugw("ignored", "k", "v")
+	logger.Info("plain")
+
+	logData := <-gotData
+	require.Equal(t, []interface{}{"created"}, logData.Message)
+	require.Equal(t, logs.Fields{
+		{Key: "module", Value: "order"},
+		{Key: "shard", Value: 3},
+		{Key: "amount", Value: 12.5},
+		{Key: "paid", Value: true},
+		{Key: "error", Value: "none"},
+		{Key: "!BADKEY", Value: "odd"},
+	}, logData.Fields)
+	require.Contains(t, logData.String(), `{"module":"order","shard":3,"amount":12.5,"paid":true,"error":"none","!BADKEY":"odd"}`)
+
+	// 父日志不受子日志字段影响
+	logData = <-gotData
+	require.Empty(t, logData.Fields)
+}
+
+func TestLogDataJSON(t *testing.T) {
+	logData := logs.NewLogData(&logs.LogCommData{LogId: "log-1", Path: "/order"})
+	logData.AddMessage(logs.WARNING, "/a/b/order.go", 12, "slow")
+	logData.AddFields(logs.Duration("cost", 1500*time.Millisecond), "count", 2, "count", 3)
+
+	out := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal([]byte(logData.JSON()), &out))
+	require.Equal(t, "WARNING", out["level"])
+	require.Equal(t, "log-1", out["id"])
+	require.Equal(t, "order.go:12", out["file"])
+	require.Equal(t, "slow", out["message"])
+	require.Equal(t, map[string]interface{}{"cost": "1.5s", "count": float64(3)}, out["fields"])
+
+	// 无法转换为json的值单独降级，不影响整条日志
+	logData = logs.NewLogData(&logs.LogCommData{
+		LogId:  "log-2",
+		Extend: map[string]interface{}{"ch": make(chan int), "fn": func() {}, "nan": math.NaN(), "name": "a"},
+	})
+	logData.AddMessage(logs.INFO, "", 0, "bad extend")
+	out = make(map[string]interface{})
+	require.NoError(t, json.Unmarshal([]byte(logData.JSON()), &out))
+	require.Equal(t, "bad extend", out["message"])
+	extend := out["extend"].(map[string]interface{})
+	require.Equal(t, "a", extend["name"])
+	require.Equal(t, "NaN", extend["nan"])
+	require.NotEmpty(t, extend["ch"])
+	require.NotEmpty(t, extend["fn"])
+}
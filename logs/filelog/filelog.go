@@ -2,6 +2,7 @@
 package filelog
 
 import (
+	"context"
 	"fmt"
 	nested "github.com/antonfisher/nested-logrus-formatter"
 	"github.com/sirupsen/logrus"
@@ -17,49 +18,110 @@ type FileLogger struct {
 	FileEndName   string //分隔后的文件名
 	RotationTime  time.Duration
 	RotationCount uint
+
+	level  logs.LogLevel
+	fields logs.Fields //With 添加的固定字段
 }
 
 // Debug 调试
 func (x *FileLogger) Debug(v ...interface{}) {
-	if x.Level() < logs.DEBUG {
-		return
-	}
-	logrus.Debug(v...)
+	x.print(logs.DEBUG, nil, v...)
 }
 
 // Error 错误
 func (x *FileLogger) Error(v ...interface{}) {
-	if x.Level() < logs.ERROR {
-		return
-	}
-	logrus.Error(v...)
+	x.print(logs.ERROR, nil, v...)
 }
 
 // Info 普通
 func (x *FileLogger) Info(v ...interface{}) {
-	if x.Level() < logs.INFO {
-		return
-	}
-	logrus.Info(v...)
+	x.print(logs.INFO, nil, v...)
 }
 
 // Warn 警告
 func (x *FileLogger) Warn(v ...interface{}) {
-	if x.Level() < logs.WARNING {
+	x.print(logs.WARNING, nil, v...)
+}
+
+// Notice 需要注意
+func (x *FileLogger) Notice(v ...interface{}) {
+	x.print(logs.NOTICE, nil, v...)
+}
+
+// Critical 严重
+func (x *FileLogger) Critical(v ...interface{}) {
+	x.print(logs.CRITICAL, nil, v...)
+}
+
+// Alert 需要立即处理
+func (x *FileLogger) Alert(v ...interface{}) {
+	x.print(logs.ALERT, nil, v...)
+}
+
+// Emergency 系统不可用
+func (x *FileLogger) Emergency(v ...interface{}) {
+	x.print(logs.EMERGENCY, nil, v...)
+}
+
+// Debugw 带结构化字段的调试
+func (x *FileLogger) Debugw(msg string, kv ...interface{}) {
+	x.print(logs.DEBUG, kv, msg)
+}
+
+// Errorw 带结构化字段的错误
+func (x *FileLogger) Errorw(msg string, kv ...interface{}) {
+	x.print(logs.ERROR, kv, msg)
+}
+
+// Infow 带结构化字段的普通
+func (x *FileLogger) Infow(msg string, kv ...interface{}) {
+	x.print(logs.INFO, kv, msg)
+}
+
+// Warnw 带结构化字段的警告
+func (x *FileLogger) Warnw(msg string, kv ...interface{}) {
+	x.print(logs.WARNING, kv, msg)
+}
+
+// With 返回带有固定字段的子日志
+func (x *FileLogger) With(kv ...interface{}) logs.ILogger {
+	child := *x
+	logData := &logs.LogData{Fields: x.fields}
+	logData.AddFields(kv...)
+	child.fields = logData.Fields
+	return &child
+}
+
+// print 按等级过滤后输出，logrus 没有的等级用相近的等级输出，并加上 severity 字段
+func (x *FileLogger) print(level logs.LogLevel, kv []interface{}, v ...interface{}) {
+	if x.Level() > level {
 		return
 	}
-	logrus.Warn(v...)
+	logData := &logs.LogData{Fields: x.fields}
+	logData.AddFields(kv...)
+	if _, ok := levelMap[level]; !ok {
+		logData.AddFields("severity", level.GetName())
+	}
+	logrus.WithFields(logData.Fields.Map()).Log(getLogrusLevel(level), v...)
+
+	if level >= logs.EMERGENCY {
+		logData.AddMessage(level, "", 0, v...)
+		logs.RunEmergencyHooks(context.Background(), logData)
+	}
 }
 
 // Level 级别
 func (x *FileLogger) Level() logs.LogLevel {
+	if x.level > 0 {
+		return x.level
+	}
 	return getLogLevel(logrus.GetLevel())
 }
 
 // SetLevel 设置
 func (x *FileLogger) SetLevel(l logs.LogLevel) {
-	fLevel := getLogrusLevel(l)
-	logrus.SetLevel(fLevel)
+	x.level = l
+	logrus.SetLevel(getLogrusLevel(l))
 }
 
 // NewFileLogger 新建文件日志
@@ -90,22 +152,23 @@ func NewFileLogger(fl *FileLogger, logLevel logs.LogLevel) logs.ILogger {
 }
 
 var levelMap = map[logs.LogLevel]logrus.Level{
-	logs.DEBUG:     logrus.DebugLevel,
-	logs.INFO:      logrus.InfoLevel,
-	logs.NOTICE:    logrus.TraceLevel,
-	logs.WARNING:   logrus.WarnLevel,
-	logs.ERROR:     logrus.ErrorLevel,
-	logs.ALERT:     logrus.FatalLevel,
-	logs.EMERGENCY: logrus.PanicLevel,
+	logs.DEBUG:   logrus.DebugLevel,
+	logs.INFO:    logrus.InfoLevel,
+	logs.WARNING: logrus.WarnLevel,
+	logs.ERROR:   logrus.ErrorLevel,
 }
 
+// getLogrusLevel logrus 的 Fatal、Panic 会退出或panic，不能用于日志等级，
+// 未定义的等级向下取最近的，高于 ERROR 的都用 ErrorLevel 输出
 func getLogrusLevel(l logs.LogLevel) logrus.Level {
-	for key, val := range levelMap {
-		if key == l {
-			return val
-		}
+	if l <= logs.DEBUG {
+		return logrus.DebugLevel
+	} else if l < logs.WARNING {
+		return logrus.InfoLevel
+	} else if l < logs.ERROR {
+		return logrus.WarnLevel
 	}
-	return logrus.WarnLevel
+	return logrus.ErrorLevel
 }
 
 func getLogLevel(l logrus.Level) logs.LogLevel {
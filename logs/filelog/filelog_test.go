@@ -0,0 +1,51 @@
+package filelog_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"github.com/tianlin0/go-plat-utils/logs/filelog"
+)
+
+func TestFileLoggerLevels(t *testing.T) {
+	logger := filelog.NewFileLogger(&filelog.FileLogger{
+		LinkName: filepath.Join(t.TempDir(), "app.log"),
+	}, logs.WARNING)
+	buf := new(bytes.Buffer)
+	logrus.SetOutput(buf)
+
+	got := make(chan *logs.LogData, 1)
+	t.Cleanup(logs.AddEmergencyHook(func(ctx context.Context, logInfo *logs.LogData) {
+		select {
+		case got <- logInfo:
+		default:
+		}
+	}))
+
+	logger.Info("info-ignored")
+	logger.Notice("notice-ignored")
+	logger.Warn("warn-logged")
+	logger.With("module", "order").Critical("critical-logged")
+	require.NotPanics(t, func() {
+		logger.Alert("alert-logged")
+		logger.Emergency("emergency-logged")
+	})
+
+	out := buf.String()
+	require.NotContains(t, out, "info-ignored")
+	require.NotContains(t, out, "notice-ignored")
+	require.Contains(t, out, "warn-logged")
+	require.Contains(t, out, "critical-logged")
+	require.Contains(t, out, "CRITICAL")
+	require.Contains(t, out, "order")
+	require.Contains(t, out, "emergency-logged")
+	require.Equal(t, logs.WARNING, logger.Level())
+
+	logData := <-got
+	require.Equal(t, []interface{}{"emergency-logged"}, logData.Message)
+}
@@ -0,0 +1,68 @@
+package logs
+
+import (
+	"context"
+	"sync"
+)
+
+// EmergencyHook EMERGENCY 级别日志的回调，比如电话告警
+type EmergencyHook func(ctx context.Context, logInfo *LogData)
+
+type emergencyHookEntry struct {
+	id   uint64
+	hook EmergencyHook
+}
+
+var (
+	emergencyHooks   []emergencyHookEntry
+	emergencyHookId  uint64
+	emergencyHooksMu sync.RWMutex
+)
+
+// AddEmergencyHook 添加 EMERGENCY 级别日志的回调，回调同步执行，耗时的操作需要自己异步处理，
+// 返回的方法用于删除本次添加的回调
+func AddEmergencyHook(hooks ...EmergencyHook) (remove func()) {
+	emergencyHooksMu.Lock()
+	defer emergencyHooksMu.Unlock()
+	ids := make(map[uint64]bool, len(hooks))
+	for _, hook := range hooks {
+		if hook != nil {
+			emergencyHookId++
+			ids[emergencyHookId] = true
+			emergencyHooks = append(emergencyHooks, emergencyHookEntry{id: emergencyHookId, hook: hook})
+		}
+	}
+	return func() {
+		emergencyHooksMu.Lock()
+		defer emergencyHooksMu.Unlock()
+		list := make([]emergencyHookEntry, 0, len(emergencyHooks))
+		for _, one := range emergencyHooks {
+			if !ids[one.id] {
+				list = append(list, one)
+			}
+		}
+		emergencyHooks = list
+	}
+}
+
+// RunEmergencyHooks 执行 EMERGENCY 级别日志的回调，自定义的 ILogger 在输出 EMERGENCY 日志后调用
+func RunEmergencyHooks(ctx context.Context, logInfo *LogData) {
+	if logInfo == nil || logInfo.LogLevel < EMERGENCY {
+		return
+	}
+	emergencyHooksMu.RLock()
+	hooks := append([]emergencyHookEntry{}, emergencyHooks...)
+	emergencyHooksMu.RUnlock()
+
+	for _, one := range hooks {
+		runEmergencyHook(ctx, one.hook, logInfo)
+	}
+}
+
+// runEmergencyHook 单个回调panic不影响日志和其他回调
+func runEmergencyHook(ctx context.Context, hook EmergencyHook, logInfo *LogData) {
+	defer func() {
+		_ = recover()
+	}()
+	hook(ctx, logInfo)
+}
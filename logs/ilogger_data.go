@@ -2,6 +2,7 @@ package logs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/tianlin0/go-plat-utils/cond"
 	"github.com/tianlin0/go-plat-utils/conf"
@@ -18,13 +19,14 @@ type (
 
 // LogCommData 不会改变的数据
 type LogCommData struct {
-	CreateTime time.Time              `json:"createTime"`       //第一条日志的创建时间
-	LogId      string                 `json:"id"`               //logId
-	UserId     string                 `json:"userid,omitempty"` //userID
-	Env        conf.EnvCode           `json:"env"`              //env
-	Path       string                 `json:"path,omitempty"`   //当前请求的地址
-	Method     string                 `json:"method,omitempty"` //当前请求的方法
-	Extend     map[string]interface{} `json:"extend,omitempty"` //额外的业务参数
+	CreateTime time.Time              `json:"createTime"`        //第一条日志的创建时间
+	LogId      string                 `json:"id"`                //logId
+	UserId     string                 `json:"userid,omitempty"`  //userID
+	TraceId    string                 `json:"traceId,omitempty"` //traceId
+	Env        conf.EnvCode           `json:"env"`               //env
+	Path       string                 `json:"path,omitempty"`    //当前请求的地址
+	Method     string                 `json:"method,omitempty"`  //当前请求的方法
+	Extend     map[string]interface{} `json:"extend,omitempty"`  //额外的业务参数
 }
 
 // LogData 每条单独日志的数据
@@ -35,6 +37,7 @@ type LogData struct {
 	Line     int           //行号
 	LogLevel LogLevel      `json:"logLevel"`
 	Message  []interface{} `json:"message"`
+	Fields   Fields        `json:"fields,omitempty"` //结构化字段
 }
 
 // Init 初始化
@@ -74,11 +77,68 @@ func (l *LogData) AddMessage(level LogLevel, fileName string, line int, msg ...i
 	l.Message = append([]interface{}{}, msg...)
 }
 
+// AddFields 添加结构化字段，参数为 Field 或 key, value 交替
+func (l *LogData) AddFields(kv ...interface{}) {
+	l.Fields = mergeFields(l.Fields, toFields(kv...))
+}
+
+// JSON 生成json字符串，字段保持原始类型
+func (l *LogData) JSON() string {
+	if len(l.Message) == 0 {
+		return ""
+	}
+	data := map[string]interface{}{
+		"time":    l.Now.Format(time.RFC3339Nano),
+		"level":   l.LogLevel.GetName(),
+		"id":      l.LogId,
+		"message": utils.Join(l.Message, " "),
+	}
+	if l.UserId != "" {
+		data["userid"] = l.UserId
+	}
+	if l.TraceId != "" {
+		data["traceId"] = l.TraceId
+	}
+	if l.Env != "" {
+		data["env"] = l.Env.String()
+	}
+	if l.FileName != "" {
+		data["file"] = fmt.Sprintf("%s:%d", filepath.Base(l.FileName), l.Line)
+	}
+	if l.Path != "" {
+		data["path"] = l.Path
+	}
+	if l.Method != "" {
+		data["method"] = l.Method
+	}
+	if len(l.Extend) > 0 {
+		extend := make(map[string]json.RawMessage, len(l.Extend))
+		for k, v := range l.Extend {
+			extend[k] = marshalValue(v)
+		}
+		data["extend"] = extend
+	}
+	if len(l.Fields) > 0 {
+		data["fields"] = l.Fields
+	}
+	if minTime := l.Now.Sub(l.CreateTime).Milliseconds(); minTime > 0 {
+		data["cost"] = minTime
+	}
+	str, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return string(str)
+}
+
 // String 生成字符串
 func (l *LogData) String() string {
 	if l.Message == nil || len(l.Message) == 0 {
 		return ""
 	}
+	if GetConfig().JSONFormat {
+		return l.JSON()
+	}
 
 	logList := make([]string, 0)
 
@@ -125,6 +185,12 @@ func (l *LogData) String() string {
 
 	logList = append(logList, fmt.Sprintf("%s", utils.Join(l.Message, " ")))
 
+	if len(l.Fields) > 0 {
+		if fieldStr, err := json.Marshal(l.Fields); err == nil {
+			logList = append(logList, string(fieldStr))
+		}
+	}
+
 	minTime := l.Now.Sub(l.CreateTime).Milliseconds()
 	if minTime > 0 {
 		logList = append(logList, fmt.Sprintf("[%dms]", minTime))
@@ -10,6 +10,11 @@ type Config struct {
 	DefaultLogger ILogger
 	LoggerCtxName string //logger在context里的名字
 	LogLevel      LogLevel
+	// DisableGoroutineContext 不再兼容按协程保存的上下文（goroutines.SetContext），
+	// 所有日志都通过 CtxLogger(ctx) 或 goroutines.Capture 显式传递ctx后可以关闭
+	DisableGoroutineContext bool
+	// JSONFormat LogData.String() 输出为json，结构化字段保持原始类型
+	JSONFormat bool
 }
 
 // GetConfig 获取默认配置
@@ -29,6 +34,12 @@ func SetConfig(cfg *Config) {
 	if cfg.LogLevel > 0 {
 		configTemp.LogLevel = cfg.LogLevel
 	}
+	if cfg.DisableGoroutineContext {
+		configTemp.DisableGoroutineContext = true
+	}
+	if cfg.JSONFormat {
+		configTemp.JSONFormat = true
+	}
 
 	// 初始化设置
 	if configTemp.LogLevel > 0 {
@@ -53,18 +64,17 @@ func CtxLogger(ctx context.Context) ILogger {
 	if ctx != nil {
 		cLogger := getCtxLoggerFromContext(ctx)
 		if cLogger != nil {
-			goroutines.SetContext(&ctx)
+			// 兼容旧的用法，保存到当前协程
+			if !GetConfig().DisableGoroutineContext {
+				goroutines.SetContext(&ctx)
+			}
 			return cLogger
 		}
 	}
 
-	// 再从全局保存的context里获取
-	oneCtxPtr, _, _ := goroutines.GetContext()
-	if oneCtxPtr != nil {
-		cLogger := getCtxLoggerFromContext(*oneCtxPtr)
-		if cLogger != nil {
-			return cLogger
-		}
+	// 再从按协程保存的context里获取，兼容旧的用法
+	if cLogger := getCtxLoggerFromContext(goroutineContext()); cLogger != nil {
+		return cLogger
 	}
 	// 再新建一个logger
 	if ctx != nil {
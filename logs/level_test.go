@@ -0,0 +1,51 @@
+package logs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+func TestLoggerAllLevels(t *testing.T) {
+	levels := make([]logs.LogLevel, 0)
+	logger, _ := logs.NewCtxLogger(context.Background(), logs.NOTICE, func(ctx context.Context, logInfo *logs.LogData) {
+		levels = append(levels, logInfo.LogLevel)
+	})
+
+	for _, l := range []logs.LogLevel{logs.DEBUG, logs.INFO, logs.NOTICE, logs.WARNING,
+		logs.ERROR, logs.CRITICAL, logs.ALERT, logs.EMERGENCY, 520, 1000} {
+		logs.Logger(logger, l, "msg")
+	}
+	require.Equal(t, []logs.LogLevel{logs.NOTICE, logs.WARNING, logs.ERROR, logs.CRITICAL,
+		logs.ALERT, logs.EMERGENCY, logs.CRITICAL, logs.EMERGENCY}, levels)
+}
+
+func TestEmergencyHook(t *testing.T) {
+	got := make(chan *logs.LogData, 1)
+	remove := logs.AddEmergencyHook(func(ctx context.Context, logInfo *logs.LogData) {
+		panic("hook panic")
+	}, func(ctx context.Context, logInfo *logs.LogData) {
+		select {
+		case got <- logInfo:
+		default:
+		}
+	})
+	t.Cleanup(remove)
+
+	logger := logs.NewPrintLogger(logs.INFO, &logs.LogCommData{LogId: "log-1"})
+	logger.Alert("not emergency")
+	logger.Emergency("db down")
+
+	logData := <-got
+	require.Equal(t, logs.EMERGENCY, logData.LogLevel)
+	require.Equal(t, "log-1", logData.LogId)
+	require.Equal(t, []interface{}{"db down"}, logData.Message)
+	require.Empty(t, got)
+
+	// 删除后不再回调
+	remove()
+	logger.Emergency("db down again")
+	require.Empty(t, got)
+}
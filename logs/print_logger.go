@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/fatih/color"
-	"github.com/tianlin0/go-plat-utils/goroutines"
 	"github.com/tianlin0/go-plat-utils/utils"
 )
 
@@ -14,6 +13,9 @@ type printLogger struct {
 	logExecute  LogExecute
 	logLevel    LogLevel
 	callerSkip  int
+	fields      Fields    //With 添加的固定字段
+	redactor    *Redactor //脱敏，为空时不处理
+	sampler     *Sampler  //采样，为空时不处理
 }
 
 // NewPrintLogger 例子，一个完整的日志，需要实现如下方法
@@ -41,6 +43,18 @@ func (x *printLogger) SetCallerSkip(skip int) *printLogger {
 	return x
 }
 
+// WithRedactor 输出前对日志脱敏
+func (x *printLogger) WithRedactor(r *Redactor) *printLogger {
+	x.redactor = r
+	return x
+}
+
+// WithSampler 按调用位置采样
+func (x *printLogger) WithSampler(s *Sampler) *printLogger {
+	x.sampler = s
+	return x
+}
+
 // WithLogExecute 自定义方法
 func (x *printLogger) WithLogExecute(logExec LogExecute) *printLogger {
 	if logExec != nil {
@@ -73,7 +87,7 @@ func defaultPrintLogExecute(ctx context.Context, logInfo *LogData) {
 	}
 }
 
-func (x *printLogger) printlnComm(level LogLevel, msg ...interface{}) {
+func (x *printLogger) printlnComm(level LogLevel, fields Fields, msg ...interface{}) {
 	if len(msg) == 0 {
 		return
 	}
@@ -88,13 +102,15 @@ func (x *printLogger) printlnComm(level LogLevel, msg ...interface{}) {
 		line = file.Line
 	}
 	logNewInfo.AddMessage(level, fileName, line, msg...)
-
-	ctxPtr, _, _ := goroutines.GetContext()
-	if ctxPtr == nil {
-		ctx := context.Background()
-		ctxPtr = &ctx
+	logNewInfo.Fields = mergeFields(x.fields, fields)
+	if !x.sampler.allow(logNewInfo, x.logExecute) {
+		return
 	}
-	x.logExecute(*ctxPtr, logNewInfo)
+	logNewInfo = x.redactor.Redact(logNewInfo)
+
+	ctx := goroutineContext()
+	x.logExecute(ctx, logNewInfo)
+	RunEmergencyHooks(ctx, logNewInfo)
 }
 
 // Debug Debug
@@ -102,7 +118,7 @@ func (x *printLogger) Debug(v ...interface{}) {
 	if x.Level() > DEBUG {
 		return
 	}
-	x.printlnComm(DEBUG, v...)
+	x.printlnComm(DEBUG, nil, v...)
 }
 
 // Error Error
@@ -110,7 +126,7 @@ func (x *printLogger) Error(v ...interface{}) {
 	if x.Level() > ERROR {
 		return
 	}
-	x.printlnComm(ERROR, v...)
+	x.printlnComm(ERROR, nil, v...)
 }
 
 // Info Info
@@ -118,7 +134,7 @@ func (x *printLogger) Info(v ...interface{}) {
 	if x.Level() > INFO {
 		return
 	}
-	x.printlnComm(INFO, v...)
+	x.printlnComm(INFO, nil, v...)
 }
 
 // Warn xx
@@ -126,7 +142,78 @@ func (x *printLogger) Warn(v ...interface{}) {
 	if x.Level() > WARNING {
 		return
 	}
-	x.printlnComm(WARNING, v...)
+	x.printlnComm(WARNING, nil, v...)
+}
+
+// Notice Notice
+func (x *printLogger) Notice(v ...interface{}) {
+	if x.Level() > NOTICE {
+		return
+	}
+	x.printlnComm(NOTICE, nil, v...)
+}
+
+// Critical Critical
+func (x *printLogger) Critical(v ...interface{}) {
+	if x.Level() > CRITICAL {
+		return
+	}
+	x.printlnComm(CRITICAL, nil, v...)
+}
+
+// Alert Alert
+func (x *printLogger) Alert(v ...interface{}) {
+	if x.Level() > ALERT {
+		return
+	}
+	x.printlnComm(ALERT, nil, v...)
+}
+
+// Emergency Emergency
+func (x *printLogger) Emergency(v ...interface{}) {
+	if x.Level() > EMERGENCY {
+		return
+	}
+	x.printlnComm(EMERGENCY, nil, v...)
+}
+
+// With 返回带有固定字段的子日志
+func (x *printLogger) With(kv ...interface{}) ILogger {
+	child := *x
+	child.fields = mergeFields(x.fields, toFields(kv...))
+	return &child
+}
+
+// Debugw 带结构化字段的 Debug
+func (x *printLogger) Debugw(msg string, kv ...interface{}) {
+	if x.Level() > DEBUG {
+		return
+	}
+	x.printlnComm(DEBUG, toFields(kv...), msg)
+}
+
+// Errorw 带结构化字段的 Error
+func (x *printLogger) Errorw(msg string, kv ...interface{}) {
+	if x.Level() > ERROR {
+		return
+	}
+	x.printlnComm(ERROR, toFields(kv...), msg)
+}
+
+// Infow 带结构化字段的 Info
+func (x *printLogger) Infow(msg string, kv ...interface{}) {
+	if x.Level() > INFO {
+		return
+	}
+	x.printlnComm(INFO, toFields(kv...), msg)
+}
+
+// Warnw 带结构化字段的 Warn
+func (x *printLogger) Warnw(msg string, kv ...interface{}) {
+	if x.Level() > WARNING {
+		return
+	}
+	x.printlnComm(WARNING, toFields(kv...), msg)
 }
 
 // Level xx
@@ -0,0 +1,299 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tianlin0/go-plat-utils/mask"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redactedValue 敏感字段替换后的值
+const redactedValue = "******"
+
+// RedactRule 按字段名脱敏的规则，字段名去掉 _ - 后不区分大小写包含 Keys 中任意一个即匹配
+type RedactRule struct {
+	Keys []string
+	Mask func(s string) string //为空时整个替换为 ******
+}
+
+// RedactPattern 按内容脱敏的规则，匹配到的部分用 Replace 替换
+type RedactPattern struct {
+	Regexp  *regexp.Regexp
+	Replace func(s string) string
+}
+
+// RedactConfig 脱敏配置
+type RedactConfig struct {
+	Rules               []RedactRule    //按字段名的规则，追加在默认规则之后
+	Patterns            []RedactPattern //按内容的规则，追加在默认规则之后
+	DisableDefaultRules bool            //不使用默认的字段名规则
+	DisablePatterns     bool            //不使用默认的内容规则（手机号、邮箱、身份证、Bearer token）
+	MaxDepth            int             //递归的最大深度，默认8
+}
+
+// DefaultRedactRules 默认的字段名规则
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{Keys: []string{"password", "passwd", "pwd", "secret", "token", "authorization", "cookie", "idcard", "idno"}},
+		{Keys: []string{"phone", "mobile"}, Mask: mask.Phone},
+		{Keys: []string{"email"}, Mask: mask.Email},
+		{Keys: []string{"realname", "truename"}, Mask: mask.RealName},
+	}
+}
+
+// DefaultRedactPatterns 默认的内容规则
+func DefaultRedactPatterns() []RedactPattern {
+	return []RedactPattern{
+		{
+			Regexp:  regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+			Replace: func(s string) string { return "Bearer " + redactedValue },
+		},
+		{
+			Regexp:  regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+			Replace: mask.Email,
+		},
+		{
+			Regexp:  regexp.MustCompile(`\b[1-9]\d{16}[\dXx]\b`),
+			Replace: func(s string) string { return mask.Character(s, 6, 4, "*") },
+		},
+		{
+			Regexp:  regexp.MustCompile(`\b1[3-9]\d{9}\b`),
+			Replace: mask.Phone,
+		},
+	}
+}
+
+// Redactor 日志脱敏，处理 Message、Fields 和 Extend
+type Redactor struct {
+	rules    []RedactRule
+	patterns []RedactPattern
+	kvReg    *regexp.Regexp //消息中 password=xxx 形式的内容
+	maxDepth int
+}
+
+// NewRedactor 新建脱敏，cfg 为空时使用默认规则
+func NewRedactor(cfg *RedactConfig) *Redactor {
+	if cfg == nil {
+		cfg = new(RedactConfig)
+	}
+	r := &Redactor{
+		maxDepth: cfg.MaxDepth,
+	}
+	if r.maxDepth <= 0 {
+		r.maxDepth = 8
+	}
+	if !cfg.DisableDefaultRules {
+		r.rules = append(r.rules, DefaultRedactRules()...)
+	}
+	r.rules = append(r.rules, cfg.Rules...)
+	if !cfg.DisablePatterns {
+		r.patterns = append(r.patterns, DefaultRedactPatterns()...)
+	}
+	r.patterns = append(r.patterns, cfg.Patterns...)
+
+	keys := make([]string, 0)
+	for _, rule := range r.rules {
+		if rule.Mask != nil {
+			continue
+		}
+		for _, key := range rule.Keys {
+			keys = append(keys, regexp.QuoteMeta(key))
+		}
+	}
+	if len(keys) > 0 {
+		r.kvReg = regexp.MustCompile(`(?i)([\w\-]*(?:` + strings.Join(keys, "|") + `)[\w\-]*["']?\s*[:=]\s*["']?)([^&\s"',;]+)`)
+	}
+	return r
+}
+
+// Wrap 在 LogExecute 前脱敏，可以包装 AsyncDispatcher.Execute 等任意处理方法
+func (r *Redactor) Wrap(next LogExecute) LogExecute {
+	return func(ctx context.Context, logInfo *LogData) {
+		next(ctx, r.Redact(logInfo))
+	}
+}
+
+// Redact 脱敏后返回新的日志数据，不修改传入的
+func (r *Redactor) Redact(logInfo *LogData) *LogData {
+	if r == nil || logInfo == nil {
+		return logInfo
+	}
+	ret := *logInfo
+	if len(logInfo.Message) > 0 {
+		ret.Message = make([]interface{}, 0, len(logInfo.Message))
+		for _, one := range logInfo.Message {
+			ret.Message = append(ret.Message, r.Value(one))
+		}
+	}
+	if len(logInfo.Fields) > 0 {
+		ret.Fields = make(Fields, 0, len(logInfo.Fields))
+		for _, one := range logInfo.Fields {
+			ret.Fields = append(ret.Fields, Field{Key: one.Key, Value: r.keyValue(one.Key, one.Value, 0)})
+		}
+	}
+	if len(logInfo.Extend) > 0 {
+		if extend, ok := r.Value(logInfo.Extend).(map[string]interface{}); ok {
+			ret.Extend = extend
+		}
+	}
+	return &ret
+}
+
+// Value 递归脱敏，map、结构体转为 map[string]interface{}，切片转为 []interface{}
+func (r *Redactor) Value(v interface{}) interface{} {
+	return r.value(v, 0)
+}
+
+// String 按内容规则脱敏字符串
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	if r.kvReg != nil {
+		s = r.kvReg.ReplaceAllString(s, "${1}"+redactedValue)
+	}
+	for _, one := range r.patterns {
+		if one.Regexp == nil || one.Replace == nil {
+			continue
+		}
+		s = one.Regexp.ReplaceAllStringFunc(s, one.Replace)
+	}
+	return s
+}
+
+// matchRule 按字段名查找规则
+func (r *Redactor) matchRule(key string) (RedactRule, bool) {
+	normalized := normalizeRedactKey(key)
+	if normalized == "" {
+		return RedactRule{}, false
+	}
+	for _, rule := range r.rules {
+		for _, one := range rule.Keys {
+			if strings.Contains(normalized, normalizeRedactKey(one)) {
+				return rule, true
+			}
+		}
+	}
+	return RedactRule{}, false
+}
+
+func normalizeRedactKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
+
+// keyValue 字段名命中规则时整体脱敏，否则递归处理值
+func (r *Redactor) keyValue(key string, v interface{}, depth int) interface{} {
+	rule, ok := r.matchRule(key)
+	if !ok {
+		return r.value(v, depth)
+	}
+	if v == nil {
+		return nil
+	}
+	if rule.Mask == nil {
+		return redactedValue
+	}
+	switch one := v.(type) {
+	case string:
+		return rule.Mask(one)
+	case fmt.Stringer:
+		return rule.Mask(one.String())
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.String || (rv.Kind() >= reflect.Int && rv.Kind() <= reflect.Uint64) {
+		return rule.Mask(fmt.Sprint(rv.Interface()))
+	}
+	return redactedValue
+}
+
+func (r *Redactor) value(v interface{}, depth int) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch one := v.(type) {
+	case string:
+		return r.String(one)
+	case []byte:
+		return r.String(string(one))
+	case error:
+		return r.String(one.Error())
+	case time.Time, time.Duration, json.Number:
+		return v
+	}
+	if depth >= r.maxDepth {
+		return r.String(fmt.Sprintf("%+v", v))
+	}
+	if marshaler, ok := v.(json.Marshaler); ok {
+		return r.marshalerValue(marshaler, depth)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return r.String(rv.String())
+	case reflect.Map:
+		ret := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			ret[key] = r.keyValue(key, iter.Value().Interface(), depth+1)
+		}
+		return ret
+	case reflect.Slice, reflect.Array:
+		ret := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			ret = append(ret, r.value(rv.Index(i).Interface(), depth+1))
+		}
+		return ret
+	case reflect.Struct:
+		if marshaler, ok := rv.Interface().(json.Marshaler); ok {
+			return r.marshalerValue(marshaler, depth)
+		}
+		rt := rv.Type()
+		ret := make(map[string]interface{}, rt.NumField())
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			ret[name] = r.keyValue(name, rv.Field(i).Interface(), depth+1)
+		}
+		return ret
+	}
+	return rv.Interface()
+}
+
+// marshalerValue 自定义了 MarshalJSON 的值按输出的json脱敏，转换失败时按字符串处理
+func (r *Redactor) marshalerValue(v json.Marshaler, depth int) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return r.String(fmt.Sprintf("%+v", v))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err = decoder.Decode(&decoded); err != nil {
+		return r.String(string(data))
+	}
+	return r.value(decoded, depth+1)
+}
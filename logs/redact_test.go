@@ -0,0 +1,124 @@
+package logs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+type redactAddress struct {
+	City  string
+	Phone string `json:"contact_phone"`
+}
+
+type redactUser struct {
+	RealName string `json:"real_name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	IdCard   int64  `json:"id_card"`
+	Remark   string `json:"remark"`
+	Address  *redactAddress
+	Tags     []string
+	internal string
+}
+
+func TestRedactorValue(t *testing.T) {
+	r := logs.NewRedactor(nil)
+
+	user := &redactUser{
+		RealName: "赵丽颖",
+		Email:    "zhangsan@go-mall.com",
+		Password: "123456",
+		IdCard:   110101199003071234,
+		Remark:   "call 13722223345 now",
+		Address:  &redactAddress{City: "sz", Phone: "13722223345"},
+		Tags:     []string{"token=abc", "ok"},
+		internal: "hidden",
+	}
+	require.Equal(t, map[string]interface{}{
+		"real_name": "赵*颖",
+		"email":     "zh****an@go-mall.com",
+		"password":  "******",
+		"id_card":   "******",
+		"remark":    "call 137****3345 now",
+		"Address": map[string]interface{}{
+			"City":          "sz",
+			"contact_phone": "137****3345",
+		},
+		"Tags": []interface{}{"token=******", "ok"},
+	}, r.Value(user))
+
+	nested := map[string]interface{}{
+		"headers": map[string]string{"Authorization": "Bearer xyz", "Accept": "json"},
+		"list": []map[string]interface{}{
+			{"mobile": 13722223345, "name": "a"},
+		},
+	}
+	require.Equal(t, map[string]interface{}{
+		"headers": map[string]interface{}{"Authorization": "******", "Accept": "json"},
+		"list": []interface{}{
+			map[string]interface{}{"mobile": "137****3345", "name": "a"},
+		},
+	}, r.Value(nested))
+
+	require.Equal(t, "login password=****** from d**r@go-mall.com with Bearer ******",
+		r.String("login password=abc123 from dear@go-mall.com with Bearer eyJhbGciOi.xx"))
+	require.Equal(t, "id 110101********1234", r.String("id 110101199003071234"))
+	require.Equal(t, "failed: secret: ******", r.Value(errors.New("failed: secret: s3cr3t")))
+}
+
+type redactAccount struct {
+	user     string
+	password string
+}
+
+func (a redactAccount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"user": a.user, "password": a.password, "age": 18})
+}
+
+func TestRedactorMarshaler(t *testing.T) {
+	r := logs.NewRedactor(nil)
+	// 自定义 MarshalJSON 的值按输出的json脱敏
+	want := map[string]interface{}{"user": "a", "password": "******", "age": json.Number("18")}
+	require.Equal(t, want, r.Value(redactAccount{user: "a", password: "p"}))
+	require.Equal(t, want, r.Value(&redactAccount{user: "a", password: "p"}))
+	require.Equal(t, map[string]interface{}{"account": want},
+		r.Value(map[string]interface{}{"account": redactAccount{user: "a", password: "p"}}))
+
+	now := time.Now()
+	require.Equal(t, now, r.Value(now))
+}
+
+func TestRedactorLogger(t *testing.T) {
+	gotData := make(chan *logs.LogData, 1)
+	logger := logs.NewPrintLogger(logs.DEBUG, &logs.LogCommData{
+		Extend: map[string]interface{}{"phone": "13722223345"},
+	}).WithLogExecute(func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	}).WithRedactor(logs.NewRedactor(&logs.RedactConfig{
+		Rules: []logs.RedactRule{{Keys: []string{"openid"}, Mask: strings.ToUpper}},
+	}))
+
+	logger.With("openid", "abc").Infow("user 13722223345 login", "access_token", "t-1", "name", "a")
+	logData := <-gotData
+	require.Equal(t, []interface{}{"user 137****3345 login"}, logData.Message)
+	require.Equal(t, logs.Fields{
+		{Key: "openid", Value: "ABC"},
+		{Key: "access_token", Value: "******"},
+		{Key: "name", Value: "a"},
+	}, logData.Fields)
+	require.Equal(t, map[string]interface{}{"phone": "137****3345"}, logData.Extend)
+
+	// 不使用脱敏的日志不受影响
+	ctxLogger, _ := logs.NewCtxLogger(context.Background(), logs.DEBUG, func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	})
+	ctxLogger.Info("user 13722223345 login")
+	require.Equal(t, []interface{}{"user 13722223345 login"}, (<-gotData).Message)
+}
@@ -0,0 +1,242 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"github.com/tianlin0/go-plat-utils/cleaner"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingConfig 按调用位置（文件:行号+等级）采样的配置，可以通过 Sampler.SetConfig 在运行时修改
+type SamplingConfig struct {
+	Disabled        bool          //关闭采样，全部输出
+	Initial         int           //每个周期内前 N 条全部输出，为0时使用默认值100，小于0时不保留，直接按 Thereafter 输出
+	Thereafter      int           //超过 Initial 后每 M 条输出1条，为0时全部丢弃
+	Interval        time.Duration //采样周期，默认1秒
+	SummaryInterval time.Duration //输出被丢弃条数汇总的间隔，默认1分钟
+	ExemptLevel     LogLevel      //大于等于该等级的日志不采样，默认 EMERGENCY
+	DisableCleaner  bool          //不注册到 cleaner，需要自己调用 Stop，只在 NewSampler 时生效
+}
+
+func (c SamplingConfig) normalize() *SamplingConfig {
+	if c.Initial == 0 {
+		c.Initial = 100
+	} else if c.Initial < 0 {
+		c.Initial = 0
+	}
+	if c.Thereafter < 0 {
+		c.Thereafter = 0
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	if c.SummaryInterval <= 0 {
+		c.SummaryInterval = time.Minute
+	}
+	if c.ExemptLevel <= 0 {
+		c.ExemptLevel = EMERGENCY
+	}
+	return &c
+}
+
+// sampleKey 调用位置
+type sampleKey struct {
+	file  string
+	line  int
+	level LogLevel
+}
+
+// sampleSite 单个调用位置的计数
+type sampleSite struct {
+	windowStart time.Time
+	count       int
+	suppressed  uint64
+	lastSeen    time.Time
+	output      LogExecute
+}
+
+// Sampler 日志采样，同一调用位置在一个周期内超过 Initial 条后按 1/Thereafter 输出，
+// 丢弃的条数按 SummaryInterval 汇总输出一条
+type Sampler struct {
+	name string
+	cfg  atomic.Pointer[SamplingConfig]
+
+	mu    sync.Mutex
+	sites map[sampleKey]*sampleSite
+
+	suppressed atomic.Uint64
+	reload     chan struct{}
+	stopCh     chan struct{}
+	done       chan struct{}
+	once       sync.Once
+}
+
+// NewSampler 新建采样，会启动汇总的协程，停止时输出最后一次汇总
+func NewSampler(cfg SamplingConfig) *Sampler {
+	s := &Sampler{
+		name:   "log-sampler",
+		sites:  make(map[sampleKey]*sampleSite),
+		reload: make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.cfg.Store(cfg.normalize())
+
+	go s.run()
+	if !cfg.DisableCleaner {
+		cleaner.Register(s)
+	}
+	return s
+}
+
+// Name 名称，实现 cleaner.Cleanable
+func (s *Sampler) Name() string {
+	return s.name
+}
+
+// Config 当前的配置
+func (s *Sampler) Config() SamplingConfig {
+	return *s.cfg.Load()
+}
+
+// SetConfig 运行时修改配置，新的周期从下一条日志开始生效
+func (s *Sampler) SetConfig(cfg SamplingConfig) {
+	s.cfg.Store(cfg.normalize())
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Suppressed 累计丢弃的条数
+func (s *Sampler) Suppressed() uint64 {
+	return s.suppressed.Load()
+}
+
+// Wrap 在 LogExecute 前采样，汇总日志也通过 next 输出
+func (s *Sampler) Wrap(next LogExecute) LogExecute {
+	return func(ctx context.Context, logInfo *LogData) {
+		if s.allow(logInfo, next) {
+			next(ctx, logInfo)
+		}
+	}
+}
+
+// Allow 是否输出该条日志，汇总日志需要通过 Wrap 或 WithSampler 使用才会输出
+func (s *Sampler) Allow(logInfo *LogData) bool {
+	return s.allow(logInfo, nil)
+}
+
+func (s *Sampler) allow(logInfo *LogData, output LogExecute) bool {
+	if s == nil || logInfo == nil {
+		return true
+	}
+	cfg := s.cfg.Load()
+	if cfg.Disabled || logInfo.LogLevel >= cfg.ExemptLevel {
+		return true
+	}
+
+	key := sampleKey{file: logInfo.FileName, line: logInfo.Line, level: logInfo.LogLevel}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	site, ok := s.sites[key]
+	if !ok {
+		site = &sampleSite{windowStart: now}
+		s.sites[key] = site
+	}
+	if output != nil {
+		site.output = output
+	}
+	site.lastSeen = now
+	if now.Sub(site.windowStart) >= cfg.Interval {
+		site.windowStart = now
+		site.count = 0
+	}
+	site.count++
+	if site.count <= cfg.Initial {
+		return true
+	}
+	if cfg.Thereafter > 0 && (site.count-cfg.Initial)%cfg.Thereafter == 0 {
+		return true
+	}
+	site.suppressed++
+	s.suppressed.Add(1)
+	return false
+}
+
+// Stop 停止汇总的协程，并输出最后一次汇总，实现 cleaner.Cleanable
+func (s *Sampler) Stop() {
+	s.once.Do(func() {
+		close(s.stopCh)
+		<-s.done
+	})
+}
+
+func (s *Sampler) run() {
+	defer close(s.done)
+
+	timer := time.NewTimer(s.cfg.Load().SummaryInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			s.summary()
+		case <-s.reload:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-s.stopCh:
+			s.summary()
+			return
+		}
+		timer.Reset(s.cfg.Load().SummaryInterval)
+	}
+}
+
+// summary 每个有丢弃的调用位置输出一条汇总，并清理长时间没有日志的位置
+func (s *Sampler) summary() {
+	cfg := s.cfg.Load()
+	now := time.Now()
+	idle := cfg.SummaryInterval
+	if cfg.Interval > idle {
+		idle = cfg.Interval
+	}
+
+	type summaryOne struct {
+		key        sampleKey
+		suppressed uint64
+		output     LogExecute
+	}
+	list := make([]summaryOne, 0)
+
+	s.mu.Lock()
+	for key, site := range s.sites {
+		if site.suppressed > 0 {
+			if site.output != nil {
+				list = append(list, summaryOne{key: key, suppressed: site.suppressed, output: site.output})
+			}
+			site.suppressed = 0
+			continue
+		}
+		if now.Sub(site.lastSeen) > idle {
+			delete(s.sites, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, one := range list {
+		logData := NewLogData()
+		logData.AddMessage(one.key.level, one.key.file, one.key.line,
+			fmt.Sprintf("[sampling] suppressed %d logs at %s:%d", one.suppressed, filepath.Base(one.key.file), one.key.line))
+		logData.AddFields(String("sampling", "summary"), Int64("suppressed", int64(one.suppressed)))
+		one.output(context.Background(), logData)
+	}
+}
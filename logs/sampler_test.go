@@ -0,0 +1,120 @@
+package logs_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+type collectExecute struct {
+	mu   sync.Mutex
+	list []*logs.LogData
+}
+
+func (c *collectExecute) execute(ctx context.Context, logInfo *logs.LogData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = append(c.list, logInfo)
+}
+
+func (c *collectExecute) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]string, 0, len(c.list))
+	for _, one := range c.list {
+		ret = append(ret, fmt.Sprint(one.Message...))
+	}
+	return ret
+}
+
+func TestSamplerCallSite(t *testing.T) {
+	sampler := logs.NewSampler(logs.SamplingConfig{
+		Initial:         2,
+		Thereafter:      3,
+		Interval:        time.Hour,
+		SummaryInterval: time.Hour,
+		DisableCleaner:  true,
+	})
+	out := new(collectExecute)
+	logger := logs.NewPrintLogger(logs.DEBUG).WithLogExecute(out.execute).WithSampler(sampler)
+
+	for i := 1; i <= 8; i++ {
+		logger.Error(fmt.Sprintf("hot%d", i))
+	}
+	logger.Error("other site")
+	logger.Emergency("never sampled")
+	require.Equal(t, []string{"hot1", "hot2", "hot5", "hot8", "other site", "never sampled"}, out.messages())
+	require.Equal(t, uint64(4), sampler.Suppressed())
+
+	sampler.Stop()
+	msgs := out.messages()
+	require.Len(t, msgs, 7)
+	require.Contains(t, msgs[6], "suppressed 4 logs at sampler_test.go:")
+	summary := out.list[6]
+	require.Equal(t, logs.ERROR, summary.LogLevel)
+	require.Equal(t, out.list[0].Line, summary.Line)
+}
+
+func TestSamplerReload(t *testing.T) {
+	sampler := logs.NewSampler(logs.SamplingConfig{
+		Initial:         1,
+		Interval:        time.Hour,
+		SummaryInterval: time.Hour,
+		DisableCleaner:  true,
+	})
+	defer sampler.Stop()
+	out := new(collectExecute)
+	execute := sampler.Wrap(out.execute)
+
+	logData := logs.NewLogData()
+	logData.AddMessage(logs.WARNING, "a.go", 10, "warn")
+	for i := 0; i < 3; i++ {
+		execute(context.Background(), logData)
+	}
+	require.Len(t, out.messages(), 1)
+
+	// 运行时修改配置，关闭采样并缩短汇总间隔
+	cfg := sampler.Config()
+	cfg.Disabled = true
+	cfg.SummaryInterval = 10 * time.Millisecond
+	sampler.SetConfig(cfg)
+	execute(context.Background(), logData)
+	require.True(t, sampler.Config().Disabled)
+
+	require.Eventually(t, func() bool {
+		msgs := out.messages()
+		return len(msgs) == 3 && msgs[2] == "[sampling] suppressed 2 logs at a.go:10"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestSamplerInitial(t *testing.T) {
+	sampler := logs.NewSampler(logs.SamplingConfig{DisableCleaner: true})
+	require.Equal(t, 100, sampler.Config().Initial)
+	sampler.Stop()
+
+	// 小于0时不保留前几条，只按 Thereafter 输出
+	sampler = logs.NewSampler(logs.SamplingConfig{
+		Initial:         -1,
+		Thereafter:      2,
+		Interval:        time.Hour,
+		SummaryInterval: time.Hour,
+		DisableCleaner:  true,
+	})
+	defer sampler.Stop()
+	require.Equal(t, 0, sampler.Config().Initial)
+
+	logData := logs.NewLogData()
+	logData.AddMessage(logs.INFO, "a.go", 10, "info")
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if sampler.Allow(logData) {
+			allowed++
+		}
+	}
+	require.Equal(t, 2, allowed)
+}
@@ -0,0 +1,247 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/lestrrat-go/file-rotatelogs"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink 异步日志的输出目标
+type Sink interface {
+	Name() string
+	Write(batch []*LogData) error
+	Close() error
+}
+
+// SinkFormat 日志格式化方法，为空时使用 LogData.String，json可传 (*LogData).JSON
+type SinkFormat func(logInfo *LogData) string
+
+func (f SinkFormat) format(logInfo *LogData) string {
+	if f == nil {
+		return logInfo.String()
+	}
+	return f(logInfo)
+}
+
+// writerSink 按行写入 io.Writer
+type writerSink struct {
+	name   string
+	mu     sync.Mutex
+	writer io.Writer
+	format SinkFormat
+}
+
+// NewWriterSink 每条日志一行写入 writer，writer 实现 io.Closer 时随 sink 一起关闭
+func NewWriterSink(name string, writer io.Writer, format SinkFormat) Sink {
+	return &writerSink{
+		name:   name,
+		writer: writer,
+		format: format,
+	}
+}
+
+// NewStdoutSink 输出到控制台
+func NewStdoutSink(format SinkFormat) Sink {
+	return &writerSink{
+		name:   "stdout",
+		writer: os.Stdout,
+		format: format,
+	}
+}
+
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+func (s *writerSink) Write(batch []*LogData) error {
+	buf := new(bytes.Buffer)
+	for _, one := range batch {
+		line := s.format.format(one)
+		if line == "" {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.writer.Write(buf.Bytes())
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if s.writer == os.Stdout || s.writer == os.Stderr {
+		return nil
+	}
+	if closer, ok := s.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RotateFileConfig 按时间切分的文件日志配置
+type RotateFileConfig struct {
+	LinkName      string        //整个文件路径+文件名，默认 logfile.log
+	FileEndName   string        //分隔后的文件名后缀，默认 .%Y%m%d
+	RotationTime  time.Duration //切分间隔，默认1天
+	RotationCount uint          //保留的文件个数，默认7个
+	Format        SinkFormat
+}
+
+// NewRotateFileSink 写入按时间切分的文件
+func NewRotateFileSink(cfg RotateFileConfig) (Sink, error) {
+	if cfg.LinkName == "" {
+		cfg.LinkName = "logfile.log"
+	}
+	if cfg.FileEndName == "" {
+		cfg.FileEndName = ".%Y%m%d"
+	}
+	if cfg.RotationTime == 0 {
+		cfg.RotationTime = time.Hour * 24
+	}
+	if cfg.RotationCount == 0 {
+		cfg.RotationCount = 7
+	}
+	writer, err := rotatelogs.New(
+		cfg.LinkName+cfg.FileEndName,
+		rotatelogs.WithLinkName(cfg.LinkName),
+		rotatelogs.WithRotationTime(cfg.RotationTime),
+		rotatelogs.WithRotationCount(cfg.RotationCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink("file:"+cfg.LinkName, writer, cfg.Format), nil
+}
+
+// HTTPSinkConfig 日志收集服务的配置，每批日志以json数组POST到 URL
+type HTTPSinkConfig struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration //默认5秒
+	Client  *http.Client
+}
+
+// httpSink 批量发送到日志收集服务
+type httpSink struct {
+	cfg HTTPSinkConfig
+}
+
+// NewHTTPSink 发送到HTTP日志收集服务
+func NewHTTPSink(cfg HTTPSinkConfig) Sink {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &httpSink{cfg: cfg}
+}
+
+func (s *httpSink) Name() string {
+	return "http:" + s.cfg.URL
+}
+
+func (s *httpSink) Write(batch []*LogData) error {
+	lines := make([]string, 0, len(batch))
+	for _, one := range batch {
+		if line := one.JSON(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	body := "[" + strings.Join(lines, ",") + "]"
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http sink status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// Message 消息队列的一条消息
+type Message struct {
+	Key   []byte
+	Value []byte
+}
+
+// MessageWriter 消息队列的写入接口，kafka 等客户端包一层即可使用
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// messageSink 写入消息队列，logId 作为消息的key
+type messageSink struct {
+	name   string
+	writer MessageWriter
+	format SinkFormat
+}
+
+// NewMessageSink 写入消息队列，format 为空时使用json
+func NewMessageSink(name string, writer MessageWriter, format SinkFormat) Sink {
+	if format == nil {
+		format = (*LogData).JSON
+	}
+	return &messageSink{
+		name:   name,
+		writer: writer,
+		format: format,
+	}
+}
+
+func (s *messageSink) Name() string {
+	return s.name
+}
+
+func (s *messageSink) Write(batch []*LogData) error {
+	msgs := make([]Message, 0, len(batch))
+	for _, one := range batch {
+		value := s.format.format(one)
+		if value == "" {
+			continue
+		}
+		msgs = append(msgs, Message{Key: []byte(one.LogId), Value: []byte(value)})
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return s.writer.WriteMessages(context.Background(), msgs...)
+}
+
+func (s *messageSink) Close() error {
+	return s.writer.Close()
+}
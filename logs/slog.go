@@ -0,0 +1,357 @@
+package logs
+
+import (
+	"context"
+	"github.com/tianlin0/go-plat-utils/utils"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slogLevelMap LogLevel 与 slog.Level 的对应关系，按等级从低到高
+var slogLevelMap = []struct {
+	level     LogLevel
+	slogLevel slog.Level
+}{
+	{DEBUG, slog.LevelDebug},
+	{INFO, slog.LevelInfo},
+	{NOTICE, slog.LevelInfo + 2},
+	{WARNING, slog.LevelWarn},
+	{ERROR, slog.LevelError},
+	{CRITICAL, slog.LevelError + 4},
+	{ALERT, slog.LevelError + 6},
+	{EMERGENCY, slog.LevelError + 8},
+}
+
+// ToSlogLevel 将 LogLevel 转为 slog.Level，未定义的等级向下取最近的
+func ToSlogLevel(l LogLevel) slog.Level {
+	ret := slogLevelMap[0].slogLevel
+	for _, one := range slogLevelMap {
+		if l < one.level {
+			break
+		}
+		ret = one.slogLevel
+	}
+	return ret
+}
+
+// FromSlogLevel 将 slog.Level 转为 LogLevel，未定义的等级向下取最近的
+func FromSlogLevel(l slog.Level) LogLevel {
+	ret := slogLevelMap[0].level
+	for _, one := range slogLevelMap {
+		if l < one.slogLevel {
+			break
+		}
+		ret = one.level
+	}
+	return ret
+}
+
+// SlogHandler 将 slog 的日志写入 LogExecute，第三方库可以通过它使用本包的日志处理
+type SlogHandler struct {
+	level       LogLevel
+	logExecute  LogExecute
+	logCommData *LogCommData
+	fields      Fields
+	groups      []string
+}
+
+// NewSlogHandler 新建 slog.Handler，logFunc 为空时优先使用ctx中日志的处理方法，否则打印到控制台
+func NewSlogHandler(level LogLevel, logFunc LogExecute, logCommData ...*LogCommData) *SlogHandler {
+	h := &SlogHandler{
+		level:      level,
+		logExecute: logFunc,
+	}
+	if h.level < DEBUG {
+		h.level = GetConfig().LogLevel
+	}
+	if len(logCommData) > 0 {
+		h.logCommData = logCommData[0]
+	}
+	return h
+}
+
+// Enabled 是否需要记录该等级的日志
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return FromSlogLevel(l) >= h.level
+}
+
+// Handle 转为 LogData 后交给 LogExecute 处理
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logExecute := h.logExecute
+	commData := h.logCommData
+	if cLogger := getCtxLoggerFromContext(ctx); cLogger != nil {
+		if logExecute == nil {
+			logExecute = cLogger.logExecute
+		}
+		if commData == nil {
+			commData = cLogger.logCommData
+		}
+	}
+	if logExecute == nil {
+		logExecute = defaultPrintLogExecute
+	}
+	if commData == nil {
+		commData = commDataFromBaggage(ctx, nil)
+	}
+
+	logData := NewLogData(commData)
+
+	fileName := ""
+	line := 0
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fileName = frame.File
+		line = frame.Line
+	}
+	logData.AddMessage(FromSlogLevel(r.Level), fileName, line, r.Message)
+	if !r.Time.IsZero() {
+		logData.Now = r.Time
+	}
+
+	fields := make(Fields, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.groups, attr)
+		return true
+	})
+	logData.Fields = mergeFields(h.fields, fields)
+
+	logExecute(ctx, logData)
+	RunEmergencyHooks(ctx, logData)
+	return nil
+}
+
+// WithAttrs 返回带有固定字段的 handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	child := *h
+	fields := make(Fields, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.groups, attr)
+	}
+	child.fields = mergeFields(h.fields, fields)
+	return &child
+}
+
+// WithGroup 返回带有分组的 handler，之后的字段名以 group. 开头
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(append([]string{}, h.groups...), name)
+	return &child
+}
+
+// appendSlogAttr 将 slog.Attr 转为字段，分组展开为 a.b 形式的字段名
+func appendSlogAttr(fields Fields, groups []string, attr slog.Attr) Fields {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		if attr.Key != "" {
+			groups = append(append([]string{}, groups...), attr.Key)
+		}
+		for _, one := range groupAttrs {
+			fields = appendSlogAttr(fields, groups, one)
+		}
+		return fields
+	}
+	key := attr.Key
+	if len(groups) > 0 {
+		key = utils.Join(append(append([]string{}, groups...), key), ".")
+	}
+	switch attr.Value.Kind() {
+	case slog.KindDuration:
+		return append(fields, Duration(key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(key, attr.Value.Time()))
+	default:
+		return append(fields, Any(key, attr.Value.Any()))
+	}
+}
+
+// slogLogger 使用 slog.Handler 输出的 ILogger
+type slogLogger struct {
+	handler    slog.Handler
+	logLevel   LogLevel
+	callerSkip int
+}
+
+// NewSlogLogger 使用任意 slog.Handler 作为 ILogger
+func NewSlogLogger(handler slog.Handler, level LogLevel) *slogLogger {
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+	x := &slogLogger{
+		handler:    handler,
+		callerSkip: 3,
+	}
+	if level >= DEBUG {
+		x.SetLevel(level)
+	} else {
+		x.SetLevel(GetConfig().LogLevel)
+	}
+	return x
+}
+
+// SetCallerSkip 设置文件忽略
+func (x *slogLogger) SetCallerSkip(skip int) *slogLogger {
+	x.callerSkip = skip
+	return x
+}
+
+// Handler 返回底层的 slog.Handler
+func (x *slogLogger) Handler() slog.Handler {
+	return x.handler
+}
+
+func (x *slogLogger) log(level LogLevel, fields Fields, msg ...interface{}) {
+	if len(msg) == 0 {
+		return
+	}
+	ctx := goroutineContext()
+	slogLevel := ToSlogLevel(level)
+	if !x.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(x.callerSkip, pcs[:])
+	r := slog.NewRecord(time.Now(), slogLevel, utils.Join(msg, " "), pcs[0])
+	for _, one := range fields {
+		r.AddAttrs(slog.Any(one.Key, one.Value))
+	}
+	_ = x.handler.Handle(ctx, r)
+
+	// SlogHandler 内部已经执行过回调
+	if _, ok := x.handler.(*SlogHandler); !ok && level >= EMERGENCY {
+		logData := NewLogData()
+		logData.AddMessage(level, "", 0, msg...)
+		logData.Fields = fields
+		RunEmergencyHooks(ctx, logData)
+	}
+}
+
+// Debug Debug
+func (x *slogLogger) Debug(v ...interface{}) {
+	if x.Level() > DEBUG {
+		return
+	}
+	x.log(DEBUG, nil, v...)
+}
+
+// Error Error
+func (x *slogLogger) Error(v ...interface{}) {
+	if x.Level() > ERROR {
+		return
+	}
+	x.log(ERROR, nil, v...)
+}
+
+// Info Info
+func (x *slogLogger) Info(v ...interface{}) {
+	if x.Level() > INFO {
+		return
+	}
+	x.log(INFO, nil, v...)
+}
+
+// Warn xx
+func (x *slogLogger) Warn(v ...interface{}) {
+	if x.Level() > WARNING {
+		return
+	}
+	x.log(WARNING, nil, v...)
+}
+
+// Notice Notice
+func (x *slogLogger) Notice(v ...interface{}) {
+	if x.Level() > NOTICE {
+		return
+	}
+	x.log(NOTICE, nil, v...)
+}
+
+// Critical Critical
+func (x *slogLogger) Critical(v ...interface{}) {
+	if x.Level() > CRITICAL {
+		return
+	}
+	x.log(CRITICAL, nil, v...)
+}
+
+// Alert Alert
+func (x *slogLogger) Alert(v ...interface{}) {
+	if x.Level() > ALERT {
+		return
+	}
+	x.log(ALERT, nil, v...)
+}
+
+// Emergency Emergency
+func (x *slogLogger) Emergency(v ...interface{}) {
+	if x.Level() > EMERGENCY {
+		return
+	}
+	x.log(EMERGENCY, nil, v...)
+}
+
+// With 返回带有固定字段的子日志，字段通过 handler.WithAttrs 保存
+func (x *slogLogger) With(kv ...interface{}) ILogger {
+	fields := toFields(kv...)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, one := range fields {
+		attrs = append(attrs, slog.Any(one.Key, one.Value))
+	}
+	child := *x
+	child.handler = x.handler.WithAttrs(attrs)
+	return &child
+}
+
+// Debugw 带结构化字段的 Debug
+func (x *slogLogger) Debugw(msg string, kv ...interface{}) {
+	if x.Level() > DEBUG {
+		return
+	}
+	x.log(DEBUG, toFields(kv...), msg)
+}
+
+// Errorw 带结构化字段的 Error
+func (x *slogLogger) Errorw(msg string, kv ...interface{}) {
+	if x.Level() > ERROR {
+		return
+	}
+	x.log(ERROR, toFields(kv...), msg)
+}
+
+// Infow 带结构化字段的 Info
+func (x *slogLogger) Infow(msg string, kv ...interface{}) {
+	if x.Level() > INFO {
+		return
+	}
+	x.log(INFO, toFields(kv...), msg)
+}
+
+// Warnw 带结构化字段的 Warn
+func (x *slogLogger) Warnw(msg string, kv ...interface{}) {
+	if x.Level() > WARNING {
+		return
+	}
+	x.log(WARNING, toFields(kv...), msg)
+}
+
+// Level xx
+func (x *slogLogger) Level() LogLevel { return x.logLevel }
+
+// SetLevel SetLevel
+func (x *slogLogger) SetLevel(l LogLevel) {
+	x.logLevel = l
+}
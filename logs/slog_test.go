@@ -0,0 +1,80 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/logs"
+)
+
+func TestSlogLevel(t *testing.T) {
+	require.Equal(t, slog.LevelDebug, logs.ToSlogLevel(logs.DEBUG))
+	require.Equal(t, slog.LevelWarn, logs.ToSlogLevel(logs.WARNING))
+	require.Equal(t, slog.LevelError, logs.ToSlogLevel(logs.ERROR))
+	for _, l := range []logs.LogLevel{logs.DEBUG, logs.INFO, logs.NOTICE, logs.WARNING,
+		logs.ERROR, logs.CRITICAL, logs.ALERT, logs.EMERGENCY} {
+		require.Equal(t, l, logs.FromSlogLevel(logs.ToSlogLevel(l)))
+	}
+	require.Equal(t, logs.DEBUG, logs.FromSlogLevel(slog.LevelDebug-4))
+	require.Equal(t, logs.INFO, logs.FromSlogLevel(slog.LevelInfo+1))
+}
+
+func TestSlogHandler(t *testing.T) {
+	gotData := make(chan *logs.LogData, 1)
+	handler := logs.NewSlogHandler(logs.INFO, func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	}, &logs.LogCommData{LogId: "log-1", UserId: "user-1", Path: "/order"})
+
+	logger := slog.New(handler).With("module", "order").WithGroup("req")
+	logger.Debug("ignored")
+	logger.Warn("slow", "cost", 12, slog.Group("db", "table", "t_order"))
+
+	logData := <-gotData
+	require.Equal(t, logs.WARNING, logData.LogLevel)
+	require.Equal(t, "log-1", logData.LogId)
+	require.Equal(t, "user-1", logData.UserId)
+	require.Equal(t, "/order", logData.Path)
+	require.Equal(t, "slog_test.go", filepath.Base(logData.FileName))
+	require.Equal(t, []interface{}{"slow"}, logData.Message)
+	require.Equal(t, logs.Fields{
+		{Key: "module", Value: "order"},
+		{Key: "req.cost", Value: int64(12)},
+		{Key: "req.db.table", Value: "t_order"},
+	}, logData.Fields)
+	require.Empty(t, gotData)
+}
+
+func TestSlogHandlerFromCtxLogger(t *testing.T) {
+	gotData := make(chan *logs.LogData, 1)
+	_, ctx := logs.NewCtxLogger(context.Background(), logs.DEBUG, func(ctx context.Context, logInfo *logs.LogData) {
+		gotData <- logInfo
+	}, &logs.LogCommData{LogId: "ctx-log"})
+
+	slog.New(logs.NewSlogHandler(logs.DEBUG, nil)).InfoContext(ctx, "from slog")
+	logData := <-gotData
+	require.Equal(t, "ctx-log", logData.LogId)
+	require.Equal(t, logs.INFO, logData.LogLevel)
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug})
+	logger := logs.NewSlogLogger(handler, logs.INFO)
+
+	logger.Debug("ignored")
+	logger.With("module", "order").Errorw("failed", logs.Int("code", 500))
+
+	out := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "ERROR", out["level"])
+	require.Equal(t, "failed", out["msg"])
+	require.Equal(t, "order", out["module"])
+	require.Equal(t, float64(500), out["code"])
+	source := out["source"].(map[string]interface{})
+	require.Equal(t, "slog_test.go", filepath.Base(source["file"].(string)))
+}
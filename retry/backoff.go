@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff 退避策略，计算第 attempt 次失败后到下一次重试的等待时间，attempt 从1开始，
+// last 为上一次的等待时间，无状态的实现可以忽略
+type Backoff interface {
+	Next(attempt int, last time.Duration) time.Duration
+}
+
+// BackoffFunc 函数形式的退避策略
+type BackoffFunc func(attempt int, last time.Duration) time.Duration
+
+// Next 实现 Backoff
+func (f BackoffFunc) Next(attempt int, last time.Duration) time.Duration {
+	return f(attempt, last)
+}
+
+// ConstantBackoff 固定间隔
+func ConstantBackoff(interval time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration {
+		return interval
+	})
+}
+
+// ExponentialBackoff 指数退避：base * 2^(attempt-1)，不超过 max
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return exponential(base, max, attempt)
+	})
+}
+
+// FullJitterBackoff 全抖动：在 [0, 指数退避值) 之间随机，避免多个客户端同时重试
+func FullJitterBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return randDuration(0, exponential(base, max, attempt))
+	})
+}
+
+// DecorrelatedJitterBackoff 去相关抖动：在 [base, last*3) 之间随机，不超过 max
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(_ int, last time.Duration) time.Duration {
+		if last < base {
+			last = base
+		}
+		return capDuration(randDuration(base, last*3), max)
+	})
+}
+
+// FibonacciBackoff 斐波那契退避：base, base, 2*base, 3*base, 5*base...，不超过 max
+func FibonacciBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		prev, cur := 0, 1
+		for i := 1; i < attempt; i++ {
+			prev, cur = cur, prev+cur
+			if time.Duration(cur)*base >= max && max > 0 {
+				return max
+			}
+		}
+		return capDuration(time.Duration(cur)*base, max)
+	})
+}
+
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := float64(base) * math.Pow(2, float64(attempt-1))
+	if max > 0 && d > float64(max) {
+		return max
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+func capDuration(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+// randDuration 在 [min, max) 之间随机
+func randDuration(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rand.Int63n(int64(max-min)))
+}
@@ -0,0 +1,88 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/commerror"
+	"github.com/tianlin0/go-plat-utils/retry"
+)
+
+func TestBackoff(t *testing.T) {
+	exp := retry.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	require.Equal(t, 10*time.Millisecond, exp.Next(1, 0))
+	require.Equal(t, 40*time.Millisecond, exp.Next(3, 0))
+	require.Equal(t, 50*time.Millisecond, exp.Next(10, 0))
+
+	fib := retry.FibonacciBackoff(time.Millisecond, time.Second)
+	var got []time.Duration
+	for i := 1; i <= 6; i++ {
+		got = append(got, fib.Next(i, 0))
+	}
+	require.Equal(t, []time.Duration{1, 1, 2, 3, 5, 8}, func() []time.Duration {
+		for i := range got {
+			got[i] /= time.Millisecond
+		}
+		return got
+	}())
+
+	full := retry.FullJitterBackoff(10*time.Millisecond, 50*time.Millisecond)
+	decorrelated := retry.DecorrelatedJitterBackoff(10*time.Millisecond, 50*time.Millisecond)
+	last := time.Duration(0)
+	for i := 1; i < 100; i++ {
+		require.Less(t, full.Next(i, 0), 50*time.Millisecond)
+		last = decorrelated.Next(i, last)
+		require.GreaterOrEqual(t, last, 10*time.Millisecond)
+		require.LessOrEqual(t, last, 50*time.Millisecond)
+	}
+}
+
+func TestRetryIf(t *testing.T) {
+	calls := 0
+	err := retry.New().WithInterval(0).WithAttemptCount(5).
+		WithRetryIf(retry.NotRetryOnCodes(403)).
+		Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			calls++
+			if calls == 2 {
+				return nil, commerror.New("forbidden", 403)
+			}
+			return nil, commerror.New("busy", 500)
+		})
+	require.Equal(t, 2, calls)
+	code, ok := commerror.GetCode(err)
+	require.True(t, ok)
+	require.Equal(t, 403, code)
+}
+
+func TestRetryAfterHint(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := retry.New().WithInterval(0).WithAttemptCount(2).
+		Do(nil, func(ctx context.Context) (interface{}, error) {
+			calls++
+			return nil, retry.WithRetryAfter(errors.New("too many requests"), 30*time.Millisecond)
+		})
+	require.NotNil(t, err)
+	require.Equal(t, 2, calls)
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	d, ok := retry.ParseRetryAfter("3")
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, d)
+}
+
+func TestMaxElapsedTime(t *testing.T) {
+	calls := 0
+	err := retry.New().WithAttemptCount(0).
+		WithBackoff(retry.ConstantBackoff(20*time.Millisecond)).
+		WithMaxElapsedTime(50*time.Millisecond).
+		Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			calls++
+			return nil, errors.New("failed")
+		})
+	require.NotNil(t, err)
+	require.Equal(t, 3, calls)
+}
@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted 重试预算已用完
+var ErrBudgetExhausted = errors.New("[retry] retry budget exhausted")
+
+// BudgetConfig 重试预算的配置
+type BudgetConfig struct {
+	Ratio               float64 `json:"ratio" yaml:"ratio"`                             // 每次成功调用增加的重试次数，默认0.1，即重试最多为成功调用的10%
+	MinRetriesPerSecond float64 `json:"minRetriesPerSecond" yaml:"minRetriesPerSecond"` // 调用量很少时，每秒至少允许的重试次数，为0时使用默认值1，小于0时不保底，只按 Ratio 积攒
+	MaxTokens           float64 `json:"maxTokens" yaml:"maxTokens"`                     // 最多积攒的重试次数，默认100
+}
+
+// Budget 令牌桶形式的重试预算，多个goroutine共享，下游故障时避免重试把流量放大
+type Budget struct {
+	cfg BudgetConfig
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewBudget 新建重试预算
+func NewBudget(cfg *BudgetConfig) *Budget {
+	c := BudgetConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Ratio <= 0 {
+		c.Ratio = 0.1
+	}
+	if c.MinRetriesPerSecond < 0 {
+		c.MinRetriesPerSecond = 0
+	} else if c.MinRetriesPerSecond == 0 {
+		c.MinRetriesPerSecond = 1
+	}
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 100
+	}
+	return &Budget{
+		cfg:        c,
+		tokens:     c.MinRetriesPerSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+// Deposit 记录一次成功调用
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.add(b.cfg.Ratio)
+}
+
+// Withdraw 申请一次重试，预算不足时返回false
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Available 当前可用的重试次数
+func (b *Budget) Available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	return b.tokens
+}
+
+// refill 按 MinRetriesPerSecond 补充令牌，需要在加锁后调用
+func (b *Budget) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	b.lastRefill = now
+	b.add(elapsed.Seconds() * b.cfg.MinRetriesPerSecond)
+}
+
+func (b *Budget) add(tokens float64) {
+	b.tokens += tokens
+	if b.tokens > b.cfg.MaxTokens {
+		b.tokens = b.cfg.MaxTokens
+	}
+}
+
+// KeyedBudget 按下游依赖区分的重试预算
+type KeyedBudget struct {
+	cfg     *BudgetConfig
+	budgets sync.Map
+}
+
+// NewKeyedBudget 新建按key区分的重试预算，每个key使用相同的配置
+func NewKeyedBudget(cfg *BudgetConfig) *KeyedBudget {
+	return &KeyedBudget{cfg: cfg}
+}
+
+// Get 取得key对应的重试预算，不存在时新建
+func (k *KeyedBudget) Get(key string) *Budget {
+	if b, ok := k.budgets.Load(key); ok {
+		return b.(*Budget)
+	}
+	b, _ := k.budgets.LoadOrStore(key, NewBudget(k.cfg))
+	return b.(*Budget)
+}
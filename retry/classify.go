@@ -0,0 +1,103 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"github.com/tianlin0/go-plat-utils/commerror"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryIfFunc 判断错误是否需要重试
+type RetryIfFunc func(err error) bool
+
+// RetryOnCodes 只有 commerror 的错误码在 codes 中时才重试
+func RetryOnCodes(codes ...int) RetryIfFunc {
+	return func(err error) bool {
+		code, ok := commerror.GetCode(err)
+		return ok && containsCode(codes, code)
+	}
+}
+
+// NotRetryOnCodes commerror 的错误码在 codes 中时不重试，如参数错误、无权限等
+func NotRetryOnCodes(codes ...int) RetryIfFunc {
+	return func(err error) bool {
+		code, ok := commerror.GetCode(err)
+		return !ok || !containsCode(codes, code)
+	}
+}
+
+// NotRetryOnCanceled ctx被取消或超时时不重试
+func NotRetryOnCanceled(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, one := range codes {
+		if one == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfterError 携带了重试等待时间的错误
+type retryAfterError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter 建议的重试等待时间
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// WithRetryAfter 给错误加上重试等待时间的提示，如下游返回 429/503 时的 Retry-After，
+// 重试时等待时间取退避策略和该值中较大的一个
+func WithRetryAfter(err error, retryAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, retryAfter: retryAfter}
+}
+
+// RetryAfterFrom 从错误中取得重试等待时间的提示，任何实现了 RetryAfter() time.Duration 的错误都可以
+func RetryAfterFrom(err error) (time.Duration, bool) {
+	var hint interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hint) {
+		return hint.RetryAfter(), true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter 解析HTTP响应头 Retry-After，支持秒数和HTTP时间两种格式
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/logs"
+	"time"
+)
+
+// Attempt 当前执行的信息
+type Attempt struct {
+	Number    int           // 第几次执行，从1开始
+	LastError error         // 上一次执行的错误，第一次执行时为nil
+	Elapsed   time.Duration // 从第一次执行开始经过的时间
+}
+
+// OnRetryFunc 重试前的回调，attempt 为刚失败的这次执行，err 为它的错误，wait 为重试前的等待时间
+type OnRetryFunc func(ctx context.Context, attempt Attempt, err error, wait time.Duration)
+
+// LogOnRetry 通过 logs.CtxLogger 记录每次重试
+func LogOnRetry(ctx context.Context, attempt Attempt, err error, wait time.Duration) {
+	logs.CtxLogger(ctx).Warn("[retry] attempt", attempt.Number, "failed:", err, "retry after", wait.String())
+}
+
+type attemptResult[T any] struct {
+	val T
+	err error
+}
+
+// DoValue 按 policy 重试执行fn，直到成功、不可重试或者次数用完，policy 为空时使用 New() 的默认配置
+func DoValue[T any](ctx context.Context, policy *retry, fn func(ctx context.Context, attempt Attempt) (T, error)) (T, error) {
+	if policy == nil {
+		policy = New()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var zero T
+	start := time.Now()
+	attempt := Attempt{}
+	var wait time.Duration
+	for {
+		attempt.Number++
+		attempt.Elapsed = time.Since(start)
+
+		val, err := runAttempt(ctx, policy, attempt, fn)
+		if err == nil {
+			if policy.budget != nil {
+				policy.budget.Deposit()
+			}
+			return val, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return zero, ctxErr
+		}
+		if !policy.shouldRetry(err) {
+			return zero, err
+		}
+		if policy.errCallFun != nil {
+			if fatalErr := policy.errCallFun(err); fatalErr != nil {
+				//表示这个是致命错误，不用重试了
+				return zero, err
+			}
+		}
+
+		var ok bool
+		if wait, ok = policy.nextWait(attempt.Number, wait, err, start); !ok {
+			return zero, fmt.Errorf("[retry] max retries exceeded (%d): %w", attempt.Number, err)
+		}
+		if policy.budget != nil && !policy.budget.Withdraw() {
+			return zero, fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+		for _, onRetry := range policy.onRetry {
+			onRetry(ctx, attempt, err, wait)
+		}
+		if sleepErr := sleepCtx(ctx, wait); sleepErr != nil {
+			return zero, sleepErr
+		}
+		attempt.LastError = err
+	}
+}
+
+// runAttempt 异步执行一次，ctx结束或者单次超时时不再等待fn返回，fn的panic会转为错误
+func runAttempt[T any](ctx context.Context, policy *retry, attempt Attempt,
+	fn func(ctx context.Context, attempt Attempt) (T, error)) (T, error) {
+	var zero T
+
+	attemptCtx := ctx
+	if policy.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, policy.attemptTimeout)
+		defer cancel()
+	}
+
+	var done func(err error)
+	if policy.breaker != nil {
+		var err error
+		if done, err = policy.breaker.Allow(); err != nil {
+			return zero, err
+		}
+	}
+
+	resultChan := make(chan attemptResult[T], 1)
+	goroutines.GoAsync(func(params ...any) {
+		ret := attemptResult[T]{err: fmt.Errorf("[retry] attempt %d panic", attempt.Number)}
+		defer func() {
+			resultChan <- ret
+		}()
+		ret.val, ret.err = fn(attemptCtx, attempt)
+	})
+
+	var ret attemptResult[T]
+	select {
+	case ret = <-resultChan:
+	case <-attemptCtx.Done():
+		ret.err = attemptCtx.Err()
+	}
+	if done != nil {
+		done(ret.err)
+	}
+	return ret.val, ret.err
+}
+
+// sleepCtx 等待d，ctx结束时提前返回
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
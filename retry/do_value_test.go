@@ -0,0 +1,68 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/retry"
+)
+
+func TestDoValue(t *testing.T) {
+	errFailed := errors.New("failed")
+	var attempts []retry.Attempt
+	var retried []int
+	val, err := retry.DoValue(context.Background(),
+		retry.New().WithInterval(time.Millisecond).WithAttemptCount(3).
+			WithOnRetry(retry.LogOnRetry, func(ctx context.Context, attempt retry.Attempt, err error, wait time.Duration) {
+				retried = append(retried, attempt.Number)
+			}),
+		func(ctx context.Context, attempt retry.Attempt) (int, error) {
+			attempts = append(attempts, attempt)
+			if attempt.Number < 3 {
+				return 0, errFailed
+			}
+			return 42, nil
+		})
+	require.Nil(t, err)
+	require.Equal(t, 42, val)
+	require.Len(t, attempts, 3)
+	require.Nil(t, attempts[0].LastError)
+	require.ErrorIs(t, attempts[2].LastError, errFailed)
+	require.Equal(t, []int{1, 2}, retried)
+
+	_, err = retry.DoValue(context.Background(), retry.New().WithInterval(0).WithAttemptCount(2),
+		func(ctx context.Context, attempt retry.Attempt) (string, error) {
+			return "", errFailed
+		})
+	require.ErrorIs(t, err, errFailed)
+}
+
+func TestDoValueAttemptTimeout(t *testing.T) {
+	var calls int32
+	val, err := retry.DoValue(context.Background(),
+		retry.New().WithInterval(0).WithAttemptCount(2).WithAttemptTimeout(20*time.Millisecond),
+		func(ctx context.Context, attempt retry.Attempt) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			if attempt.Number == 1 {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return "ok", nil
+		})
+	require.Nil(t, err)
+	require.Equal(t, "ok", val)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDoNilValue(t *testing.T) {
+	ret := "unchanged"
+	err := retry.New().Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	}, &ret)
+	require.Nil(t, err)
+	require.Equal(t, "unchanged", ret)
+}
@@ -0,0 +1,192 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"github.com/tianlin0/go-plat-utils/goroutines"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyTracker 记录最近若干次调用的耗时，用于计算分位数
+type LatencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyTracker 新建耗时统计，size 为保留的样本数，默认1000
+func NewLatencyTracker(size int) *LatencyTracker {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LatencyTracker{samples: make([]time.Duration, size)}
+}
+
+// Observe 记录一次耗时
+func (l *LatencyTracker) Observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.samples[l.next] = d
+	l.next++
+	if l.next == len(l.samples) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// Percentile 返回分位数耗时，p 取值 (0,1]，如0.95，没有样本时返回false
+func (l *LatencyTracker) Percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	n := l.next
+	if l.full {
+		n = len(l.samples)
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, l.samples[:n])
+	l.mu.Unlock()
+
+	if n == 0 {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(n)*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= n {
+		idx = n - 1
+	}
+	return sorted[idx], true
+}
+
+// HedgeConfig 对冲请求的配置
+type HedgeConfig struct {
+	Percentile float64       `json:"percentile" yaml:"percentile"` // 第一次请求超过该分位数耗时仍未返回时发起对冲请求，默认0.95
+	Delay      time.Duration `json:"delay" yaml:"delay"`           // 还没有耗时样本时使用的等待时间，默认100毫秒
+	MinDelay   time.Duration `json:"minDelay" yaml:"minDelay"`     // 等待时间下限
+	MaxDelay   time.Duration `json:"maxDelay" yaml:"maxDelay"`     // 等待时间上限，0为不限
+	MaxHedges  int           `json:"maxHedges" yaml:"maxHedges"`   // 最多额外发起的请求数，默认1
+
+	// Tracker 耗时统计，为空时自动新建，多个 Hedger 可以共享
+	Tracker *LatencyTracker `json:"-" yaml:"-"`
+	// Budget 对冲请求消耗的预算，为空时不限制
+	Budget *Budget `json:"-" yaml:"-"`
+}
+
+// Hedger 对冲请求执行器
+type Hedger struct {
+	cfg HedgeConfig
+}
+
+// NewHedger 新建对冲请求执行器
+func NewHedger(cfg *HedgeConfig) *Hedger {
+	c := HedgeConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Percentile <= 0 || c.Percentile > 1 {
+		c.Percentile = 0.95
+	}
+	if c.Delay <= 0 {
+		c.Delay = 100 * time.Millisecond
+	}
+	if c.MaxHedges <= 0 {
+		c.MaxHedges = 1
+	}
+	if c.Tracker == nil {
+		c.Tracker = NewLatencyTracker(0)
+	}
+	return &Hedger{cfg: c}
+}
+
+// Tracker 耗时统计
+func (h *Hedger) Tracker() *LatencyTracker {
+	return h.cfg.Tracker
+}
+
+// hedgeDelay 发起对冲请求前的等待时间
+func (h *Hedger) hedgeDelay() time.Duration {
+	delay, ok := h.cfg.Tracker.Percentile(h.cfg.Percentile)
+	if !ok {
+		delay = h.cfg.Delay
+	}
+	if delay < h.cfg.MinDelay {
+		delay = h.cfg.MinDelay
+	}
+	return capDuration(delay, h.cfg.MaxDelay)
+}
+
+// Hedge 执行fn，超过分位数耗时未返回时再发起一次，返回最先成功的结果并取消其他请求，
+// attempt 为第几个请求，从1开始，全部失败时返回最后一个错误
+func Hedge[T any](ctx context.Context, h *Hedger, fn func(ctx context.Context, attempt int) (T, error)) (T, error) {
+	if h == nil {
+		h = NewHedger(nil)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := h.cfg.MaxHedges + 1
+	resultChan := make(chan attemptResult[T], total)
+	launched := 0
+	launch := func() {
+		launched++
+		attempt := launched
+		goroutines.GoAsync(func(params ...any) {
+			start := time.Now()
+			ret := attemptResult[T]{err: fmt.Errorf("[retry] hedge attempt %d panic", attempt)}
+			defer func() {
+				resultChan <- ret
+			}()
+			ret.val, ret.err = fn(ctx, attempt)
+			if ret.err == nil {
+				h.cfg.Tracker.Observe(time.Since(start))
+			}
+		})
+	}
+
+	var zero T
+	var lastErr error
+	finished := 0
+	launch()
+	timer := time.NewTimer(h.hedgeDelay())
+	defer timer.Stop()
+	for {
+		select {
+		case ret := <-resultChan:
+			finished++
+			if ret.err == nil {
+				if h.cfg.Budget != nil {
+					h.cfg.Budget.Deposit()
+				}
+				return ret.val, nil
+			}
+			lastErr = ret.err
+			if finished < launched {
+				continue
+			}
+			// 全部失败，还有对冲名额时立即发起，否则返回
+			if launched >= total || ctx.Err() != nil || !h.allowHedge() {
+				return zero, lastErr
+			}
+			launch()
+		case <-timer.C:
+			if launched < total && h.allowHedge() {
+				launch()
+			}
+			if launched < total {
+				timer.Reset(h.hedgeDelay())
+			}
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func (h *Hedger) allowHedge() bool {
+	return h.cfg.Budget == nil || h.cfg.Budget.Withdraw()
+}
@@ -0,0 +1,102 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tianlin0/go-plat-utils/retry"
+)
+
+func TestBudget(t *testing.T) {
+	b := retry.NewBudget(&retry.BudgetConfig{Ratio: 0.5, MinRetriesPerSecond: -1, MaxTokens: 2})
+	require.False(t, b.Withdraw())
+
+	b.Deposit()
+	b.Deposit()
+	require.True(t, b.Withdraw())
+	require.False(t, b.Withdraw())
+
+	for i := 0; i < 10; i++ {
+		b.Deposit()
+	}
+	require.Equal(t, float64(2), b.Available())
+
+	// MinRetriesPerSecond 为0时使用默认的每秒1次
+	b = retry.NewBudget(&retry.BudgetConfig{})
+	require.True(t, b.Withdraw())
+
+	keyed := retry.NewKeyedBudget(nil)
+	require.Same(t, keyed.Get("db"), keyed.Get("db"))
+	require.NotSame(t, keyed.Get("db"), keyed.Get("cache"))
+}
+
+func TestRetryWithBudget(t *testing.T) {
+	errFailed := errors.New("failed")
+	b := retry.NewBudget(&retry.BudgetConfig{Ratio: 1, MinRetriesPerSecond: -1})
+	b.Deposit()
+
+	calls := 0
+	_, err := retry.DoValue(context.Background(), retry.New().WithInterval(0).WithAttemptCount(5).WithBudget(b),
+		func(ctx context.Context, attempt retry.Attempt) (int, error) {
+			calls++
+			return 0, errFailed
+		})
+	require.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	require.ErrorIs(t, err, errFailed)
+	// 预算只够重试一次
+	require.Equal(t, 2, calls)
+}
+
+func TestHedge(t *testing.T) {
+	h := retry.NewHedger(&retry.HedgeConfig{Delay: 20 * time.Millisecond})
+	var cancelled int32
+	start := time.Now()
+	val, err := retry.Hedge(context.Background(), h, func(ctx context.Context, attempt int) (int, error) {
+		if attempt == 1 {
+			<-ctx.Done()
+			atomic.AddInt32(&cancelled, 1)
+			return 0, ctx.Err()
+		}
+		return attempt, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, 2, val)
+	require.Less(t, time.Since(start), time.Second)
+	// 返回后取消了第一个请求
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cancelled) == 1
+	}, time.Second, time.Millisecond)
+
+	// 快速返回时不发起对冲请求，上面的耗时样本很小，用新的 Hedger 以免立即对冲
+	h = retry.NewHedger(&retry.HedgeConfig{Delay: 20 * time.Millisecond, MinDelay: 20 * time.Millisecond})
+	var mu sync.Mutex
+	attempts := map[int]bool{}
+	_, err = retry.Hedge(context.Background(), h, func(ctx context.Context, attempt int) (int, error) {
+		mu.Lock()
+		attempts[attempt] = true
+		mu.Unlock()
+		return 1, nil
+	})
+	require.Nil(t, err)
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	require.Equal(t, map[int]bool{1: true}, attempts)
+	mu.Unlock()
+}
+
+func TestLatencyTracker(t *testing.T) {
+	l := retry.NewLatencyTracker(100)
+	_, ok := l.Percentile(0.95)
+	require.False(t, ok)
+	for i := 1; i <= 100; i++ {
+		l.Observe(time.Duration(i) * time.Millisecond)
+	}
+	p, ok := l.Percentile(0.95)
+	require.True(t, ok)
+	require.Equal(t, 95*time.Millisecond, p)
+}
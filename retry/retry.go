@@ -3,16 +3,24 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/tianlin0/go-plat-utils/goroutines"
+	"github.com/tianlin0/go-plat-utils/breaker"
 	"reflect"
 	"time"
 )
 
 type retry struct {
-	attemptCount int             //最大尝试次数
-	interval     time.Duration   //间隔时间
-	errCallFun   ErrCallbackFunc //执行错误的方法
+	attemptCount   int              //最大尝试次数
+	interval       time.Duration    //间隔时间
+	backoff        Backoff          //退避策略，为空时使用固定间隔 interval
+	maxElapsedTime time.Duration    //从第一次执行开始的总时长上限，0为不限
+	retryIf        RetryIfFunc      //判断错误是否需要重试，为空时都重试
+	errCallFun     ErrCallbackFunc  //执行错误的方法
+	breaker        *breaker.Breaker //熔断器，打开时停止重试
+	attemptTimeout time.Duration    //每次执行的超时时间，0为不限
+	onRetry        []OnRetryFunc    //每次重试前的回调
+	budget         *Budget          //重试预算，用完时不再重试
 }
 
 type Executable func(context.Context) (interface{}, error)
@@ -46,137 +54,128 @@ func (r *retry) WithAttemptCount(attemptCount int) *retry {
 	return r
 }
 
+// WithBackoff 设置退避策略，会覆盖 WithInterval 的固定间隔
+func (r *retry) WithBackoff(backoff Backoff) *retry {
+	r.backoff = backoff
+	return r
+}
+
+// WithMaxElapsedTime 设置总时长上限，下一次重试会超过该时长时不再重试
+func (r *retry) WithMaxElapsedTime(maxElapsedTime time.Duration) *retry {
+	r.maxElapsedTime = maxElapsedTime
+	return r
+}
+
+// WithRetryIf 设置判断错误是否需要重试的方法，返回false时立即返回该错误
+func (r *retry) WithRetryIf(retryIf RetryIfFunc) *retry {
+	r.retryIf = retryIf
+	return r
+}
+
 // WithErrCallback 设置错误回调函数, 每次执行时有任何错误都会报告给该函数
 func (r *retry) WithErrCallback(errFun ErrCallbackFunc) *retry {
 	r.errCallFun = errFun
 	return r
 }
 
-// Do 执行一个函数
-func (r *retry) Do(parentCtx context.Context, f Executable, valuePtr ...interface{}) error {
-	if len(valuePtr) > 0 && valuePtr != nil {
-		if valuePtr[0] != nil {
-			rf := reflect.ValueOf(valuePtr[0])
-			if rf.Type().Kind() != reflect.Ptr {
-				return fmt.Errorf("valuePtr parameter is not a pointer")
-			}
+// WithAttemptTimeout 设置每次执行的超时时间，超时计为一次失败
+func (r *retry) WithAttemptTimeout(timeout time.Duration) *retry {
+	r.attemptTimeout = timeout
+	return r
+}
+
+// WithOnRetry 添加重试前的回调，如 LogOnRetry 记录日志
+func (r *retry) WithOnRetry(onRetry ...OnRetryFunc) *retry {
+	for _, one := range onRetry {
+		if one != nil {
+			r.onRetry = append(r.onRetry, one)
 		}
 	}
+	return r
+}
 
-	var retData interface{}
-	var err error
-	if parentCtx != nil {
-		retData, err = r.doRetryWithCtx(parentCtx, f)
-	} else {
-		retData, err = r.doRetry(f)
-	}
+// WithBudget 设置重试预算，成功时增加预算，每次重试消耗预算，预算用完时返回 ErrBudgetExhausted
+func (r *retry) WithBudget(b *Budget) *retry {
+	r.budget = b
+	return r
+}
 
-	if len(valuePtr) > 0 && valuePtr != nil {
-		if valuePtr[0] != nil {
-			rf := reflect.ValueOf(valuePtr[0])
-			if rf.Elem().CanSet() {
-				fv := reflect.ValueOf(retData)
-				isSet := false
-				if fv.Kind() == reflect.Ptr && fv.Type() == rf.Type() {
-					if fv.Elem().IsValid() {
-						isSet = true
-						rf.Elem().Set(fv.Elem())
-					}
-				} else {
-					if fv.IsValid() {
-						isSet = true
-						rf.Elem().Set(fv)
-					}
-				}
-				if !isSet {
-					if err == nil {
-						err = fmt.Errorf("call Return: call of reflect.Value.Set on zero Value")
-					}
-				}
-			}
-		}
+// WithBreaker 设置熔断器，每次执行都经过熔断器，熔断器打开时不再重试，直接返回 breaker.ErrOpen
+func (r *retry) WithBreaker(b *breaker.Breaker) *retry {
+	r.breaker = b
+	return r
+}
+
+// shouldRetry 错误是否需要重试
+func (r *retry) shouldRetry(err error) bool {
+	if isBreakerOpen(err) {
+		return false
 	}
-	return err
+	return r.retryIf == nil || r.retryIf(err)
 }
 
-// DoCtx 执行一个函数
-func (r *retry) doRetryWithCtx(parentCtx context.Context, fn Executable) (interface{}, error) {
-	ctx, cancel := context.WithCancel(parentCtx)
-	defer cancel()
-
-	nowAttemptCount := 0
-	fail := make(chan error, 1)
-	success := make(chan interface{}, 1)
-
-	for {
-		goroutines.GoAsync(func(params ...any) {
-			val, err := fn(ctx)
-			if err != nil {
-				fail <- err
-				return
-			}
-			success <- val
-		})
-
-		select {
-		//
-		case <-parentCtx.Done():
-			return nil, parentCtx.Err()
-		case err := <-fail:
-			if parentCtxErr := parentCtx.Err(); parentCtxErr != nil {
-				return nil, parentCtxErr
-			}
-
-			nowError := fmt.Errorf("Max retries exceeded (%v).\n", nowAttemptCount)
-
-			if r.errCallFun != nil {
-				errError := r.errCallFun(err)
-				if errError != nil {
-					return nil, nowError
-				}
-			}
-
-			nowAttemptCount++
-
-			if nowAttemptCount >= r.attemptCount {
-				return nil, nowError
-			}
-
-			if r.interval > 0 {
-				time.Sleep(r.interval)
-			}
-
-		case val := <-success:
-			return val, nil
-		}
+// nextWait 第 attempt 次失败后，判断是否继续重试，返回下一次重试前的等待时间
+func (r *retry) nextWait(attempt int, lastWait time.Duration, err error, start time.Time) (time.Duration, bool) {
+	if r.attemptCount > 0 && attempt >= r.attemptCount {
+		return 0, false
+	}
+
+	wait := r.interval
+	if r.backoff != nil {
+		wait = r.backoff.Next(attempt, lastWait)
 	}
+	// 下游给出了等待时间，至少要等待这么久
+	if hint, ok := RetryAfterFrom(err); ok && hint > wait {
+		wait = hint
+	}
+	if r.maxElapsedTime > 0 && time.Since(start)+wait > r.maxElapsedTime {
+		return 0, false
+	}
+	return wait, true
 }
 
-// doRetry 执行一个函数
-func (r *retry) doRetry(f Executable) (val interface{}, err error) {
-	nowAttemptCount := 0
-	parentCtx := context.Background()
-	for {
-		nowAttemptCount++
+// isBreakerOpen 熔断器拒绝了请求，重试也没有意义
+func isBreakerOpen(err error) bool {
+	return errors.Is(err, breaker.ErrOpen) || errors.Is(err, breaker.ErrTooManyRequests)
+}
 
-		val, err = f(parentCtx)
-		if err == nil {
-			return
-		}
-		if r.errCallFun != nil {
-			errError := r.errCallFun(err)
-			if errError != nil {
-				//表示这个是致命错误，不用重试了
-				return
-			}
+// Do 执行一个函数，valuePtr 不为空时将返回值写入其中
+func (r *retry) Do(parentCtx context.Context, f Executable, valuePtr ...interface{}) error {
+	var rf reflect.Value
+	if len(valuePtr) > 0 && valuePtr[0] != nil {
+		rf = reflect.ValueOf(valuePtr[0])
+		if rf.Type().Kind() != reflect.Ptr {
+			return fmt.Errorf("valuePtr parameter is not a pointer")
 		}
+	}
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	}
 
-		if nowAttemptCount >= r.attemptCount {
-			return
-		}
+	retData, err := DoValue(parentCtx, r, func(ctx context.Context, _ Attempt) (interface{}, error) {
+		return f(ctx)
+	})
+	if !rf.IsValid() || !rf.Elem().CanSet() {
+		return err
+	}
 
-		if r.interval > 0 {
-			time.Sleep(r.interval)
+	// 返回nil时不修改 valuePtr
+	fv := reflect.ValueOf(retData)
+	if fv.Kind() == reflect.Ptr && fv.Type() == rf.Type() {
+		if fv.IsNil() {
+			return err
 		}
+		fv = fv.Elem()
 	}
+	if !fv.IsValid() {
+		return err
+	}
+	if !fv.Type().AssignableTo(rf.Elem().Type()) {
+		if err == nil {
+			err = fmt.Errorf("call Return: %s is not assignable to %s", fv.Type(), rf.Elem().Type())
+		}
+		return err
+	}
+	rf.Elem().Set(fv)
+	return err
 }